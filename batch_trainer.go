@@ -7,152 +7,322 @@ import (
 	"math"
 	"math/rand"
 	"os"
-	"time"
+	"runtime"
+	"sync"
 )
 
-// Paramètres entraînables offline
-type TrainParams struct {
-	rayonAide        float64
-	MaxHelpersPerHit int
-	tailleIndice     float64
-	tauxExploration  float64
-	dureeEngagement  float64
+// Options du batch d'entraînement
+type TrainOptions struct {
+	Config     inputFile // scénario de base
+	OutputPath string    // fichier de policy écrit en sortie
+	Workers    int       // nb de workers en parallèle (<= 0 : un par CPU)
+	Seed       int64     // graine maître de l'optimiseur (0 = aléatoire), sans effet sur les graines des runs
+	Optimizer  string    // random, grid, genetic, cmaes
+	Budget     int       // nb de combinaisons évaluées (<= 0 : 80)
+	TrainSeeds int       // graines fixes d'entraînement par combinaison (<= 0 : 5)
+	HoldOut    int       // graines held-out pour valider la meilleure policy (<= 0 : 30)
+	Profile    string    // profil de score imposé (vide = celui de la config)
+	ParetoPath string    // front de Pareto écrit en sortie (vide = pareto_front.json à côté de OutputPath)
+	Fleet      bool      // optimise aussi la composition de la flotte (catalogue + budget de la config)
 }
 
-// Lance le batch d'entraînement offline et écrit best_policy.json
-func RunBatchTraining() {
-	baseCfg := loadConfig("config.json")
+// Une combinaison à évaluer, avec les graines de ses répétitions
+type trainJob struct {
+	index  int
+	policy LearnedPolicyConfig
+	seeds  []int64
+}
 
-	rand.Seed(time.Now().UnixNano())
+// Résultat moyen d'une combinaison
+type trainResult struct {
+	index      int
+	policy     LearnedPolicyConfig
+	score      float64
+	stats      SimStats
+	objectives Objectives
+}
 
-	const numCandidates = 80     // nombre de combinaisons testées
-	const runsPerCandidate = 5   // nb de répétitions par combinaison
-	const maxStepsPerSim = 20000 // sécurité
+// Lance le batch d'entraînement offline et écrit la meilleure policy
+func RunBatchTraining(opts TrainOptions) error {
+	baseCfg, err := loadConfig(opts.Config)
+	if err != nil {
+		return err
+	}
+	if opts.Profile != "" {
+		baseCfg.Scoring = ScoringConfig{Profile: opts.Profile}
+	}
 
-	bestScore := math.Inf(-1)
-	var bestParams TrainParams
-	var bestStats SimStats
+	const maxStepsPerSim = defaultMaxSteps // sécurité
+
+	budget := opts.Budget
+	if budget <= 0 {
+		budget = 80 // nombre de combinaisons testées
+	}
+	masterSeed := opts.Seed
+	if masterSeed == 0 {
+		masterSeed = randomSeed()
+	}
+	workers := opts.Workers
+	if workers <= 0 {
+		workers = runtime.NumCPU()
+	}
+	if opts.TrainSeeds <= 0 {
+		opts.TrainSeeds = 5 // nb de répétitions par combinaison
+	}
+	if opts.HoldOut <= 0 {
+		opts.HoldOut = 30
+	}
+	// toutes les combinaisons sont jouées sur les mêmes graines : les écarts
+	// de score viennent des paramètres, pas du tirage des scénarios. Elles ne
+	// dépendent pas de la graine maître, pour comparer deux entraînements.
+	seedSets := fixedSeedSets(1, opts.TrainSeeds, opts.HoldOut)
 
 	fmt.Println("=== Batch training drones (offline) ===")
 	fmt.Printf("Base scenario: survivors=%d, traces=%d, droneTypes=%d\n",
 		baseCfg.NumSurvivors, baseCfg.NumTraces, len(baseCfg.DroneTypes))
 
-	for i := 0; i < numCandidates; i++ {
-		p := randomTrainParams()
-
-		var totalScore float64
-		var aggStats SimStats
+	space := defaultSearchSpace()
+	if opts.Fleet {
+		if space, err = fleetSearchSpace(baseCfg.catalog(), baseCfg.budget()); err != nil {
+			return err
+		}
+		fmt.Printf("Recherche de flotte : %d types au catalogue, budget=%.0f€\n", len(space.catalog), space.budget)
+	}
 
-		for r := 0; r < runsPerCandidate; r++ {
-			cfg := applyTrainParams(baseCfg, p)
-			stats := runSimulationOnce(cfg, maxStepsPerSim)
+	// L'optimiseur tire tout depuis la graine maître, séquentiellement :
+	// le résultat ne dépend donc pas du nombre de workers.
+	opt, err := newOptimizer(opts.Optimizer, space, budget, rand.New(rand.NewSource(masterSeed)))
+	if err != nil {
+		return err
+	}
+	fmt.Printf("Optimiseur=%s, budget=%d, graine maître=%d, workers=%d, score=%s\n",
+		opt.Name(), budget, masterSeed, workers, baseCfg.Scoring.resolved().Profile)
 
-			s := scoreStats(stats)
-			totalScore += s
+	bestScore := math.Inf(-1)
+	var best LearnedPolicyConfig
+	var bestStats SimStats
+	var points []ParetoPoint
 
-			aggStats.TotalTime += stats.TotalTime
-			aggStats.SavedSurvivors += stats.SavedSurvivors
-			aggStats.TotalSurvivors = stats.TotalSurvivors
+	evaluated := 0
+	for evaluated < budget {
+		batch := opt.Propose(budget - evaluated)
+		if len(batch) == 0 {
+			break
 		}
-
-		avgScore := totalScore / float64(runsPerCandidate)
-		avgStats := SimStats{
-			TotalTime:      aggStats.TotalTime / float64(runsPerCandidate),
-			SavedSurvivors: int(math.Round(float64(aggStats.SavedSurvivors) / float64(runsPerCandidate))),
-			TotalSurvivors: aggStats.TotalSurvivors,
+		jobs := make([]trainJob, len(batch))
+		for i, c := range batch {
+			jobs[i] = trainJob{
+				index:  evaluated + i,
+				policy: space.learned(c),
+				seeds:  seedSets.Train,
+			}
 		}
 
-		fmt.Printf("[candidat %2d] rayonAide=%.1f, MaxHelpers=%d, TraceFactor=%.2f, Explore=%.3f, Timeout=%.1fs -> score=%.2f, avgTime=%.1fs, saved=%d/%d\n",
-			i, p.rayonAide, p.MaxHelpersPerHit, p.tailleIndice, p.tauxExploration, p.dureeEngagement,
-			avgScore, avgStats.TotalTime, avgStats.SavedSurvivors, avgStats.TotalSurvivors)
+		results := evaluateJobs(baseCfg, jobs, workers, maxStepsPerSim)
+
+		scores := make([]float64, len(results))
+		for i, res := range results {
+			scores[i] = res.score
+			p := res.policy
+			fmt.Printf("[candidat %2d] rayonAide=%.1f, MaxHelpers=%d, TraceFactor=%.2f, Explore=%.3f, Timeout=%.1fs%s -> score=%.2f, avgTime=%.1fs, saved=%d/%d\n",
+				res.index, p.RayonAide, p.MaxHelpersPerHit, p.TailleIndice, p.TauxExploration, p.DureeEngagement, fleetSuffix(p),
+				res.score, res.stats.TotalTime, res.stats.SavedSurvivors, res.stats.TotalSurvivors)
 
-		if avgScore > bestScore {
-			bestScore = avgScore
-			bestParams = p
-			bestStats = avgStats
+			if res.score > bestScore {
+				bestScore = res.score
+				best = p
+				bestStats = res.stats
+			}
+			points = append(points, ParetoPoint{
+				Candidate:  res.index,
+				Policy:     p,
+				Objectives: res.objectives,
+				Score:      res.score,
+			})
 		}
+		opt.Observe(batch, scores)
+		evaluated += len(batch)
 	}
 
 	fmt.Println("\n=== MEILLEURE CONFIG TROUVÉE ===")
-	fmt.Printf("rayonAide=%.1f, MaxHelpers=%d, TraceFactor=%.2f, Explore=%.3f, Timeout=%.1fs\n",
-		bestParams.rayonAide, bestParams.MaxHelpersPerHit, bestParams.tailleIndice, bestParams.tauxExploration, bestParams.dureeEngagement)
+	fmt.Printf("rayonAide=%.1f, MaxHelpers=%d, TraceFactor=%.2f, Explore=%.3f, Timeout=%.1fs%s\n",
+		best.RayonAide, best.MaxHelpersPerHit, best.TailleIndice, best.TauxExploration, best.DureeEngagement, fleetSuffix(best))
 	fmt.Printf("Performance moyenne: sauvés=%d/%d, temps moyen=%.1fs, score=%.2f\n",
 		bestStats.SavedSurvivors, bestStats.TotalSurvivors, bestStats.TotalTime, bestScore)
 
-	// Sauvegarde dans best_policy.json
-	policy := LearnedPolicyConfig{
-		rayonAide:        bestParams.rayonAide,
-		MaxHelpersPerHit: bestParams.MaxHelpersPerHit,
-		tailleIndice:     bestParams.tailleIndice,
-		tauxExploration:  bestParams.tauxExploration,
-		dureeEngagement:  bestParams.dureeEngagement,
+	// Validation sur les graines held-out, comparée à la policy actuelle si elle existe
+	bestCfg := applyTrainParams(baseCfg, best)
+	bestEval := evaluatePolicy(bestCfg, seedSets.HoldOut, workers, maxStepsPerSim)
+	printEvalReport(os.Stdout, "Meilleure policy, graines held-out", bestEval)
+	if prev, ok, _ := loadBestPolicy(inputFile{Path: opts.OutputPath}); ok {
+		prevCfg := applyTrainParams(baseCfg, prev)
+		prevEval := evaluatePolicy(prevCfg, seedSets.HoldOut, workers, maxStepsPerSim)
+		fmt.Printf("\nComparaison avec la policy actuelle (%s), nouvelle - actuelle :\n", opts.OutputPath)
+		if cmp, err := comparePolicies(prevEval, bestEval); err == nil {
+			printComparisons(os.Stdout, cmp)
+			if !cmp[0].Significant {
+				fmt.Println("ATTENTION : l'écart de score avec la policy actuelle n'est pas statistiquement significatif.")
+			}
+		}
 	}
-	if err := saveBestPolicy("best_policy.json", policy); err != nil {
-		log.Println("Erreur lors de l'écriture de best_policy.json:", err)
+
+	// Sauvegarde de la policy (best_policy.json par défaut)
+	if err := saveBestPolicy(opts.OutputPath, best); err != nil {
+		log.Printf("Erreur lors de l'écriture de %s: %v", opts.OutputPath, err)
 	} else {
-		fmt.Println("\nFichier best_policy.json écrit avec succès.")
+		fmt.Printf("\nFichier %s écrit avec succès.\n", opts.OutputPath)
 		fmt.Println("Au prochain lancement du serveur web, cette politique sera utilisée par défaut.")
 	}
+
+	// Front de Pareto multi-objectif de tous les candidats évalués
+	paretoPath := opts.ParetoPath
+	if paretoPath == "" {
+		paretoPath = defaultParetoPath(opts.OutputPath)
+	}
+	front := paretoFront(points)
+	fmt.Printf("\n=== FRONT DE PARETO (%d points sur %d candidats) ===\n", len(front.Points), len(points))
+	for i, pt := range front.Points {
+		o := pt.Objectives
+		fmt.Printf("[%d] candidat %d : sauvés=%.1f%%, temps=%.1fs, énergie=%.1f, coût=%.0f€\n",
+			i, pt.Candidate, 100*o.RescueRate, o.TotalTime, o.Energy, o.FleetCost)
+	}
+	if err := saveParetoFront(paretoPath, front); err != nil {
+		log.Printf("Erreur lors de l'écriture de %s: %v", paretoPath, err)
+	} else {
+		fmt.Printf("Fichier %s écrit (serve -front %s -point <index|knee|...>).\n", paretoPath, paretoPath)
+	}
+	return nil
 }
 
-// Plages de recherche
-func randomTrainParams() TrainParams {
-	return TrainParams{
-		rayonAide:        100 + rand.Float64()*300,   // 100 à 400
-		MaxHelpersPerHit: 1 + rand.Intn(6),           // 1 à 6
-		tailleIndice:     1.0 + rand.Float64()*2.0,   // 1.0 à 3.0
-		tauxExploration:  0.01 + rand.Float64()*0.19, // 0.01 à 0.20
-		dureeEngagement:  3.0 + rand.Float64()*9.0,   // 3 à 12 secondes
+// Évalue les combinaisons sur un pool de workers.
+// Chaque simulation a son propre RNG : les résultats sont rangés par index
+// et identiques à une exécution séquentielle.
+func evaluateJobs(baseCfg SimConfig, jobs []trainJob, workers, maxSteps int) []trainResult {
+	results := make([]trainResult, len(jobs))
+	parallelFor(len(jobs), workers, func(i int) {
+		results[i] = evaluateJob(baseCfg, jobs[i], maxSteps)
+	})
+	return results
+}
+
+// Lance la même config sur plusieurs graines en parallèle (résultats dans l'ordre des graines)
+func runSeeds(cfg SimConfig, seeds []int64, workers, maxSteps int) []SimStats {
+	stats := make([]SimStats, len(seeds))
+	parallelFor(len(seeds), workers, func(i int) {
+		c := cfg
+		c.Seed = seeds[i]
+		stats[i] = runSimulationOnce(c, maxSteps)
+	})
+	return stats
+}
+
+// Exécute fn(0..n-1) sur un pool de workers
+func parallelFor(n, workers int, fn func(i int)) {
+	if workers <= 0 {
+		workers = runtime.NumCPU()
+	}
+	if workers > n {
+		workers = n
+	}
+
+	queue := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range queue {
+				fn(i)
+			}
+		}()
+	}
+	for i := 0; i < n; i++ {
+		queue <- i
+	}
+	close(queue)
+	wg.Wait()
+}
+
+// Évalue une combinaison sur toutes ses graines et moyenne les résultats
+func evaluateJob(baseCfg SimConfig, job trainJob, maxSteps int) trainResult {
+	var totalScore float64
+	var aggStats SimStats
+	var obj Objectives
+
+	for _, seed := range job.seeds {
+		cfg := applyTrainParams(baseCfg, job.policy)
+		cfg.Seed = seed
+		stats := runSimulationOnce(cfg, maxSteps)
+
+		totalScore += scoreStats(stats, cfg.Scoring)
+
+		aggStats.TotalTime += stats.TotalTime
+		aggStats.SavedSurvivors += stats.SavedSurvivors
+		aggStats.TotalSurvivors = stats.TotalSurvivors
+
+		obj.RescueRate += rescueRate(stats)
+		obj.TotalTime += stats.TotalTime
+		obj.Energy += stats.EnergyConsumed
+		obj.FleetCost += stats.FleetCost
+	}
+
+	runs := float64(len(job.seeds))
+	return trainResult{
+		index:  job.index,
+		policy: job.policy,
+		score:  totalScore / runs,
+		objectives: Objectives{
+			RescueRate: obj.RescueRate / runs,
+			TotalTime:  obj.TotalTime / runs,
+			Energy:     obj.Energy / runs,
+			FleetCost:  obj.FleetCost / runs,
+		},
+		stats: SimStats{
+			TotalTime:      aggStats.TotalTime / runs,
+			SavedSurvivors: int(math.Round(float64(aggStats.SavedSurvivors) / runs)),
+			TotalSurvivors: aggStats.TotalSurvivors,
+		},
 	}
 }
 
-// Applique les params à la config de base
-func applyTrainParams(base SimConfig, p TrainParams) SimConfig {
+// Applique les params (et la flotte éventuelle) à la config de base
+func applyTrainParams(base SimConfig, pol LearnedPolicyConfig) SimConfig {
 	cfg := base
-	cfg.rayonAide = p.rayonAide
-	cfg.MaxHelpersPerHit = p.MaxHelpersPerHit
-	cfg.tailleIndice = p.tailleIndice
-	cfg.tauxExploration = p.tauxExploration
-	cfg.dureeEngagement = p.dureeEngagement
+	cfg.applyLearned(pol)
 	return cfg
 }
 
+func fleetSuffix(pol LearnedPolicyConfig) string {
+	if len(pol.Fleet) == 0 {
+		return ""
+	}
+	return ", flotte=" + describeFleet(pol.Fleet)
+}
+
 // Lance UNE simulation hors-ligne jusqu'à la fin ou maxSteps
 func runSimulationOnce(cfg SimConfig, maxSteps int) SimStats {
-	s := NewSimulation(cfg)
+	return runSimulation(cfg, maxSteps).Stats
+}
+
+// Comme runSimulationOnce, mais renvoie l'environnement final complet
+func runSimulation(cfg SimConfig, maxSteps int) Environment {
+	return finishRun(NewSimulation(cfg), maxSteps)
+}
 
-	for i := 0; i < maxSteps; i++ {
+// Fait tourner s jusqu'à la fin ou maxSteps pas
+func finishRun(s *Simulation, maxSteps int) Environment {
+	for i := 0; i < maxSteps && !s.env.Finished; i++ {
 		s.step() // fonction interne, même package
-		env := s.Snapshot()
-		if env.Finished {
-			return env.Stats
-		}
 	}
 
-	// si la simu n'a pas fini, on renvoie quand même des stats
 	env := s.Snapshot()
-	stats := env.Stats
-	stats.TotalTime = env.Time
-	stats.TotalSurvivors = len(env.Survivors)
-	stats.SavedSurvivors = 0
-	for _, sv := range env.Survivors {
-		if sv.Saved {
-			stats.SavedSurvivors++
-		}
+	if env.Finished {
+		return env
 	}
-	return stats
-}
 
-// Fonction de score : plus de survivants et moins de temps = meilleur
-func scoreStats(stats SimStats) float64 {
-	if stats.TotalSurvivors == 0 {
-		return -1e9
-	}
-	if stats.SavedSurvivors == 0 {
-		return -1e9
-	}
-	survivalRate := float64(stats.SavedSurvivors) / float64(stats.TotalSurvivors)
-	return survivalRate*1000.0 - stats.TotalTime
+	// si la simu n'a pas fini, on renvoie quand même des stats
+	env.Stats = env.computeStats()
+	return env
 }
 
 // Sauvegarde le best policy en JSON
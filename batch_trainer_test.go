@@ -0,0 +1,64 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// Écrit cfg dans un fichier de config temporaire
+func writeTestConfig(t *testing.T, cfg SimConfig) string {
+	t.Helper()
+	data, err := json.Marshal(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(t.TempDir(), "config.json")
+	if err := os.WriteFile(path, data, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestBatchTrainingIndependentOfWorkers(t *testing.T) {
+	cfg := defaultConfig()
+	cfg.NumSurvivors = 3
+	configPath := writeTestConfig(t, cfg)
+
+	train := func(workers int) (policy, front []byte) {
+		dir := t.TempDir()
+		opts := TrainOptions{
+			Config:     inputFile{Path: configPath, Required: true},
+			OutputPath: filepath.Join(dir, "policy.json"),
+			Workers:    workers,
+			Seed:       7,
+			Optimizer:  "genetic",
+			Budget:     8,
+			TrainSeeds: 2,
+			HoldOut:    2,
+		}
+		if err := RunBatchTraining(opts); err != nil {
+			t.Fatal(err)
+		}
+		policy, err := os.ReadFile(opts.OutputPath)
+		if err != nil {
+			t.Fatal(err)
+		}
+		front, err = os.ReadFile(defaultParetoPath(opts.OutputPath))
+		if err != nil {
+			t.Fatal(err)
+		}
+		return policy, front
+	}
+
+	p1, f1 := train(1)
+	p4, f4 := train(4)
+	if !bytes.Equal(p1, p4) {
+		t.Errorf("best policy differs:\n%s\n%s", p1, p4)
+	}
+	if !bytes.Equal(f1, f4) {
+		t.Error("Pareto front differs between 1 and 4 workers")
+	}
+}
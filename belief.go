@@ -0,0 +1,84 @@
+package main
+
+import "fmt"
+
+//
+// ------------------------ Connaissance des drones ------------------------
+//
+// En mode omniscient, les drones qui explorent lisent la heatmap globale. En
+// mode décentralisé, chaque drone ne connaît que sa propre carte de
+// couverture : ses passages, plus ce qu'il apprend des cartes jointes aux
+// messages qu'il reçoit et de la carte de la base, échangée à chaque recharge.
+
+type KnowledgeMode string
+
+const (
+	KnowledgeOmniscient    KnowledgeMode = "omniscient"    // heatmap globale (défaut)
+	KnowledgeDecentralized KnowledgeMode = "decentralized" // carte propre à chaque drone
+)
+
+const beliefCell = 20.0 // même grille que la heatmap
+
+func validateKnowledge(k KnowledgeMode) error {
+	switch k {
+	case "", KnowledgeOmniscient, KnowledgeDecentralized:
+		return nil
+	}
+	return fmt.Errorf("connaissance inconnue %q (omniscient, decentralized)", k)
+}
+
+// Carte de couverture : passages connus par cellule, indexée comme la heatmap
+type BeliefMap [][]float64
+
+func newBeliefMap(cfg *SimConfig) BeliefMap {
+	b := make(BeliefMap, int(cfg.Width/beliefCell))
+	for i := range b {
+		b[i] = make([]float64, int(cfg.Height/beliefCell))
+	}
+	return b
+}
+
+// Compte un passage en (x, y)
+func (b BeliefMap) observe(x, y float64) {
+	ix, iy := int(x/beliefCell), int(y/beliefCell)
+	if ix >= 0 && iy >= 0 && ix < len(b) && iy < len(b[ix]) {
+		b[ix][iy]++
+	}
+}
+
+// Fusionne une autre carte : on garde pour chaque cellule le plus grand
+// nombre de passages connu, ce qui ne compte jamais deux fois la même information
+func (b BeliefMap) merge(o BeliefMap) {
+	for i := range b {
+		if i >= len(o) {
+			return
+		}
+		for j := range b[i] {
+			if j < len(o[i]) && o[i][j] > b[i][j] {
+				b[i][j] = o[i][j]
+			}
+		}
+	}
+}
+
+func (b BeliefMap) clone() BeliefMap {
+	c := make(BeliefMap, len(b))
+	for i := range b {
+		c[i] = append([]float64(nil), b[i]...)
+	}
+	return c
+}
+
+// Carte lue par le drone pour choisir où explorer
+func (d *DroneAgent) coverage(env *Environment) [][]float64 {
+	if env.Config.Knowledge == KnowledgeDecentralized {
+		return d.belief
+	}
+	return env.Heatmap
+}
+
+// Recharge à une station : le drone et la base mettent leurs cartes en commun
+func (d *DroneAgent) syncWithBase(env *Environment) {
+	d.belief.merge(env.BaseBelief)
+	env.BaseBelief.merge(d.belief)
+}
@@ -0,0 +1,32 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestBeliefMergeIsIdempotent(t *testing.T) {
+	cfg := &SimConfig{Width: 100, Height: 60}
+	a, b := newBeliefMap(cfg), newBeliefMap(cfg)
+	for _, p := range []Point{{10, 10}, {10, 10}, {50, 30}} {
+		a.observe(p.X, p.Y)
+	}
+	for _, p := range []Point{{10, 10}, {90, 50}, {90, 50}, {90, 50}} {
+		b.observe(p.X, p.Y)
+	}
+
+	a.merge(b)
+	once := a.clone()
+	a.merge(b)
+	if !reflect.DeepEqual(a, once) {
+		t.Errorf("second merge changed the map:\n%v\n%v", once, a)
+	}
+	a.merge(a.clone())
+	if !reflect.DeepEqual(a, once) {
+		t.Error("merging a map with itself changed it")
+	}
+	// maximum par cellule, sans additionner les passages
+	if a[0][0] != 2 || a[2][1] != 1 || a[4][2] != 3 {
+		t.Errorf("merged counts %v, %v, %v; want 2, 1, 3", a[0][0], a[2][1], a[4][2])
+	}
+}
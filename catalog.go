@@ -0,0 +1,219 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+const defaultBudget = 100000 // budget flotte en euros
+
+// Catalogue par défaut (servi à l'interface web par /api/catalog)
+func defaultCatalog() []DroneType {
+	return []DroneType{
+		{Name: "scout", Label: "Éclaireur", Count: 5, Speed: 90, Weight: 1.0, Autonomy: 600, DetectionRadius: 30, Price: 6000},
+		{Name: "standard", Label: "Standard", Count: 3, Speed: 60, Weight: 2.0, Autonomy: 1000, DetectionRadius: 50, Price: 10000},
+		{Name: "heavy", Label: "Lourd", Count: 2, Speed: 30, Weight: 5.0, Autonomy: 1600, DetectionRadius: 80, Price: 20000},
+	}
+}
+
+// Catalogue de la config, ou catalogue par défaut
+func (c SimConfig) catalog() []DroneType {
+	if len(c.Catalog) > 0 {
+		return c.Catalog
+	}
+	return defaultCatalog()
+}
+
+// Budget de la config, ou budget par défaut
+func (c SimConfig) budget() float64 {
+	if c.Budget > 0 {
+		return c.Budget
+	}
+	return defaultBudget
+}
+
+// Flotte construite à partir du nombre de drones par type du catalogue
+func fleetFromCounts(catalog []DroneType, counts []int) []DroneType {
+	var fleet []DroneType
+	for i, n := range counts {
+		if n <= 0 {
+			continue
+		}
+		dt := catalog[i]
+		dt.Count = n
+		fleet = append(fleet, dt)
+	}
+	return fleet
+}
+
+// Coût d'une composition (nb de drones par type du catalogue)
+func countsCost(catalog []DroneType, counts []int) float64 {
+	var cost float64
+	for i, n := range counts {
+		cost += float64(n) * catalog[i].Price
+	}
+	return cost
+}
+
+// Ramène une composition sous le budget en retirant des drones du type
+// qui pèse le plus dans la dépense ; garantit au moins un drone. Erreur si
+// même un seul drone du modèle le moins cher dépasse le budget.
+func repairFleet(catalog []DroneType, counts []int, budget float64) error {
+	if len(catalog) == 0 {
+		return fmt.Errorf("catalogue vide")
+	}
+	cheapest := 0
+	for i, dt := range catalog {
+		if dt.Price < catalog[cheapest].Price {
+			cheapest = i
+		}
+	}
+	if catalog[cheapest].Price > budget {
+		return fmt.Errorf("aucun drone ne tient dans le budget de %.0f€ (%s : %.0f€)",
+			budget, catalog[cheapest].Name, catalog[cheapest].Price)
+	}
+
+	for countsCost(catalog, counts) > budget {
+		worst := -1
+		for i, n := range counts {
+			if n > 0 && (worst < 0 || float64(n)*catalog[i].Price > float64(counts[worst])*catalog[worst].Price) {
+				worst = i
+			}
+		}
+		if worst < 0 {
+			break
+		}
+		counts[worst]--
+	}
+
+	total := 0
+	for _, n := range counts {
+		total += n
+	}
+	if total == 0 {
+		counts[cheapest] = 1
+	}
+	return nil
+}
+
+// Copie de la flotte où les types sans prix prennent celui du modèle de même
+// nom au catalogue, s'il a les mêmes caractéristiques (les droneTypes d'une
+// config n'ont souvent pas de prix)
+func priceFleet(catalog, fleet []DroneType) []DroneType {
+	if len(fleet) == 0 {
+		return fleet
+	}
+	out := make([]DroneType, len(fleet))
+	for i, dt := range fleet {
+		if dt.Price <= 0 {
+			if c, ok := lookupDroneType(catalog, dt.Name); ok && sameModel(dt, c) {
+				dt.Price = c.Price
+			}
+		}
+		out[i] = dt
+	}
+	return out
+}
+
+// Mêmes caractéristiques que le modèle c, hors nom, libellé, nombre et prix
+func sameModel(dt, c DroneType) bool {
+	dt.Name, dt.Label, dt.Count, dt.Price = c.Name, c.Label, c.Count, c.Price
+	return dt == c
+}
+
+// Vérifie que chaque type de la flotte d'une config a un prix : le sien, ou
+// celui du modèle identique de même nom au catalogue
+func validateDroneTypes(catalog, fleet []DroneType) error {
+	for _, dt := range fleet {
+		if dt.Price > 0 {
+			continue
+		}
+		c, ok := lookupDroneType(catalog, dt.Name)
+		if !ok {
+			return fmt.Errorf("type de drone %q sans prix et absent du catalogue", dt.Name)
+		}
+		if !sameModel(dt, c) {
+			return fmt.Errorf("type de drone %q sans prix et différent du modèle %q du catalogue : donner son price", dt.Name, c.Name)
+		}
+	}
+	return nil
+}
+
+// "scout:3, heavy:1 (38000€)"
+func describeFleet(fleet []DroneType) string {
+	parts := make([]string, 0, len(fleet))
+	for _, dt := range fleet {
+		parts = append(parts, fmt.Sprintf("%s:%d", dt.Name, dt.Count))
+	}
+	return fmt.Sprintf("%s (%.0f€)", strings.Join(parts, ", "), fleetCost(fleet))
+}
+
+// Modèle du catalogue portant ce nom (insensible à la casse)
+func lookupDroneType(catalog []DroneType, name string) (DroneType, bool) {
+	for _, dt := range catalog {
+		if strings.EqualFold(dt.Name, name) {
+			return dt, true
+		}
+	}
+	return DroneType{}, false
+}
+
+// Valide une flotte demandée par un client : seuls le nom et le nombre
+// comptent, les caractéristiques et le prix viennent du catalogue.
+func validateFleet(cfg SimConfig, requested []DroneType) ([]DroneType, *APIError) {
+	catalog := cfg.catalog()
+	var fleet []DroneType
+	for _, req := range requested {
+		if req.Count < 0 {
+			return nil, &APIError{
+				Code:    "invalid_count",
+				Message: fmt.Sprintf("nombre de drones négatif pour %q", req.Name),
+				Details: map[string]any{"type": req.Name, "count": req.Count},
+			}
+		}
+		dt, ok := lookupDroneType(catalog, req.Name)
+		if !ok {
+			known := make([]string, len(catalog))
+			for i, c := range catalog {
+				known[i] = c.Name
+			}
+			return nil, &APIError{
+				Code:    "unknown_drone_type",
+				Message: fmt.Sprintf("type de drone inconnu %q", req.Name),
+				Details: map[string]any{"type": req.Name, "known": known},
+			}
+		}
+		if req.Count == 0 {
+			continue
+		}
+		dt.Count = req.Count
+		fleet = append(fleet, dt)
+	}
+	if len(fleet) == 0 {
+		return nil, &APIError{Code: "empty_fleet", Message: "la flotte ne contient aucun drone"}
+	}
+
+	cost, budget := fleetCost(fleet), cfg.budget()
+	if cost > budget {
+		return nil, &APIError{
+			Code:    "over_budget",
+			Message: fmt.Sprintf("flotte à %.0f€ pour un budget de %.0f€", cost, budget),
+			Details: map[string]any{"cost": cost, "budget": budget},
+		}
+	}
+	return fleet, nil
+}
+
+// Réponse de /api/catalog
+type CatalogResponse struct {
+	Budget     float64     `json:"budget"`
+	DroneTypes []DroneType `json:"droneTypes"`
+}
+
+func (m *SessionManager) handleCatalog(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, CatalogResponse{
+		Budget:     m.base.budget(),
+		DroneTypes: m.base.catalog(),
+	})
+}
@@ -0,0 +1,103 @@
+package main
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestRepairFleet(t *testing.T) {
+	catalog := defaultCatalog() // scout 6000, standard 10000, heavy 20000
+	for _, tc := range []struct {
+		name   string
+		counts []int
+		budget float64
+		want   []int
+	}{
+		{"already within budget", []int{2, 1, 1}, 42000, []int{2, 1, 1}},
+		{"exactly the budget", []int{5, 3, 2}, 100000, []int{5, 3, 2}},
+		// heavy pèse le plus (3 x 20000) : on le réduit d'abord
+		{"over budget", []int{2, 0, 3}, 50000, []int{2, 0, 1}},
+		{"empty fleet gets the cheapest drone", []int{0, 0, 0}, 100000, []int{1, 0, 0}},
+		{"budget of a single cheapest drone", []int{3, 2, 1}, 6000, []int{1, 0, 0}},
+	} {
+		counts := slices.Clone(tc.counts)
+		if err := repairFleet(catalog, counts, tc.budget); err != nil {
+			t.Errorf("%s: %v", tc.name, err)
+			continue
+		}
+		if !slices.Equal(counts, tc.want) {
+			t.Errorf("%s: repairFleet(%v, %.0f) = %v, want %v", tc.name, tc.counts, tc.budget, counts, tc.want)
+		}
+		if cost := countsCost(catalog, counts); cost > tc.budget {
+			t.Errorf("%s: cost %.0f over budget %.0f", tc.name, cost, tc.budget)
+		}
+	}
+
+	if err := repairFleet(catalog, []int{1, 0, 0}, 5999); err == nil {
+		t.Error("a budget below the cheapest drone was accepted")
+	}
+	if _, err := fleetSearchSpace(catalog, 5999); err == nil {
+		t.Error("fleet search space built for an unaffordable budget")
+	}
+}
+
+func TestValidateFleet(t *testing.T) {
+	cfg := defaultConfig() // catalogue par défaut, budget 100000
+	code := func(fleet ...DroneType) string {
+		_, apiErr := validateFleet(cfg, fleet)
+		if apiErr == nil {
+			return ""
+		}
+		return apiErr.Code
+	}
+	for _, tc := range []struct {
+		name  string
+		fleet []DroneType
+		want  string
+	}{
+		{"exactly the budget", []DroneType{{Name: "heavy", Count: 5}}, ""},
+		{"one drone over", []DroneType{{Name: "heavy", Count: 5}, {Name: "scout", Count: 1}}, "over_budget"},
+		{"client price ignored", []DroneType{{Name: "heavy", Count: 6, Price: 1}}, "over_budget"},
+		{"negative count", []DroneType{{Name: "scout", Count: -1}}, "invalid_count"},
+		{"unknown type", []DroneType{{Name: "jet", Count: 1}}, "unknown_drone_type"},
+		{"only zero counts", []DroneType{{Name: "scout", Count: 0}}, "empty_fleet"},
+		{"case-insensitive name", []DroneType{{Name: "Scout", Count: 2}}, ""},
+	} {
+		if got := code(tc.fleet...); got != tc.want {
+			t.Errorf("%s: code %q, want %q", tc.name, got, tc.want)
+		}
+	}
+
+	fleet, apiErr := validateFleet(cfg, []DroneType{{Name: "standard", Count: 2, Price: 1, Speed: 999}})
+	if apiErr != nil {
+		t.Fatal(apiErr)
+	}
+	if fleet[0].Price != 10000 || fleet[0].Speed != 60 {
+		t.Errorf("fleet = %+v, want catalog characteristics", fleet[0])
+	}
+}
+
+func TestFleetPriceNeedsSameModel(t *testing.T) {
+	catalog := defaultCatalog()
+	same := catalog[2]
+	same.Count, same.Price = 4, 0
+	other := same
+	other.Speed, other.Weight = 40, 3 // un autre appareil sous le nom heavy
+
+	priced := priceFleet(catalog, []DroneType{same, other})
+	if priced[0].Price != 20000 || priced[1].Price != 0 {
+		t.Errorf("prices %v and %v, want 20000 and 0", priced[0].Price, priced[1].Price)
+	}
+	if err := validateDroneTypes(catalog, []DroneType{same}); err != nil {
+		t.Errorf("catalog model rejected: %v", err)
+	}
+	for _, dt := range []DroneType{other, {Name: "custom", Count: 1, Speed: 50}} {
+		if err := validateDroneTypes(catalog, []DroneType{dt}); err == nil {
+			t.Errorf("%s without a price accepted", dt.Name)
+		}
+	}
+	other.Price = 16000
+	if err := validateDroneTypes(catalog, []DroneType{other}); err != nil {
+		t.Errorf("priced type rejected: %v", err)
+	}
+}
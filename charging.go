@@ -0,0 +1,151 @@
+package main
+
+import (
+	"math"
+	"slices"
+)
+
+//
+// ------------------------ Stations de charge ------------------------
+//
+// Un point de charge a Pads emplacements qui chargent chacun à ChargeRate W.
+// Un drone qui arrive se pose sur un emplacement libre (mode charging) ou
+// prend sa place dans la file FIFO (mode waiting). Le drone qui rentre choisit
+// la station qui minimise trajet + attente prévue parmi celles qu'il peut
+// atteindre avec son énergie.
+
+const (
+	defaultPads       = 2
+	defaultChargeTime = 10.0 // s pour une charge complète quand ChargeRate n'est pas donné
+	dockDistance      = 5.0  // distance à laquelle un drone se pose sur la station
+)
+
+// Stations de la simulation : copie de la config avec emplacements et files vides
+func newStations(points []ChargingPoint) []ChargingPoint {
+	out := make([]ChargingPoint, len(points))
+	for i, cp := range points {
+		if cp.Pads <= 0 {
+			cp.Pads = defaultPads
+		}
+		cp.Charging = []int{}
+		cp.Queue = []int{}
+		out[i] = cp
+	}
+	return out
+}
+
+func cloneStations(points []ChargingPoint) []ChargingPoint {
+	out := make([]ChargingPoint, len(points))
+	for i, cp := range points {
+		cp.Charging = slices.Clone(cp.Charging)
+		cp.Queue = slices.Clone(cp.Queue)
+		out[i] = cp
+	}
+	return out
+}
+
+func sameStation(a, b ChargingPoint) bool {
+	return slices.Equal(a.Charging, b.Charging) && slices.Equal(a.Queue, b.Queue)
+}
+
+// Puissance de charge d'un emplacement pour ce drone (W)
+func (cp *ChargingPoint) rate(dr *Drone) float64 {
+	if cp.ChargeRate > 0 {
+		return cp.ChargeRate
+	}
+	return dr.Battery * 3600 / defaultChargeTime
+}
+
+// Temps pour recharger complètement le drone (s)
+func (cp *ChargingPoint) chargeTime(dr *Drone) float64 {
+	return (dr.Battery - dr.RemainingEnergy) * 3600 / cp.rate(dr)
+}
+
+// Attente prévue (s) pour un drone qui arriverait maintenant : les
+// emplacements se libèrent au rythme des charges en cours, en attente et des
+// drones déjà en route vers la station
+func (env *Environment) expectedWait(cp *ChargingPoint, self int) float64 {
+	var work float64
+	users := 0
+	for i := range env.Drones {
+		d := &env.Drones[i]
+		if d.ID == self || d.Station != cp.ID {
+			continue
+		}
+		if d.Mode == ModeCharging || d.Mode == ModeWaiting || d.Mode == ModeReturning {
+			work += cp.chargeTime(d)
+			users++
+		}
+	}
+	if users < cp.Pads {
+		return 0
+	}
+	return work / float64(cp.Pads)
+}
+
+// Station choisie par un drone qui rentre : trajet planifié (vent compris) + attente
+// minimal parmi les stations atteignables avec la réserve, sinon la plus proche
+func (env *Environment) chooseStation(dr *Drone) int {
+	best, bestCost := -1, math.Inf(1)
+	nearest, nearestDist := -1, math.Inf(1)
+	for i := range env.ChargingPoints {
+		cp := &env.ChargingPoints[i]
+		dist := distance(dr.X, dr.Y, cp.X, cp.Y)
+		if dist < nearestDist {
+			nearest, nearestDist = i, dist
+		}
+		// même marge de sécurité que le déclenchement du retour
+		energy := env.energyToReach(dr, cp.X, cp.Y)
+		if energy*(1+env.Config.EnergyReserve) > dr.RemainingEnergy {
+			continue
+		}
+		// à vitesse air constante, la durée du trajet est énergie / puissance
+		cost := energy*3600/dr.Airframe.power(dr.Speed) + env.expectedWait(cp, dr.ID)
+		if cost < bestCost {
+			best, bestCost = i, cost
+		}
+	}
+	if best < 0 {
+		return nearest
+	}
+	return best
+}
+
+// Le drone arrive à sa station : il se pose sur un emplacement libre ou fait la queue
+func (env *Environment) dock(dr *Drone) {
+	cp := &env.ChargingPoints[dr.Station]
+	dr.Vx, dr.Vy = 0, 0
+	dr.HasTarget = false
+	dr.Route = nil
+	if len(cp.Charging) < cp.Pads {
+		cp.Charging = append(cp.Charging, dr.ID)
+		dr.Mode = ModeCharging
+		env.logEvent(Event{Type: EventChargeStarted, Drone: dr.ID, ChargingPoint: ref(cp.ID)})
+		return
+	}
+	cp.Queue = append(cp.Queue, dr.ID)
+	dr.Mode = ModeWaiting
+	env.logEvent(Event{Type: EventChargeQueued, Drone: dr.ID, ChargingPoint: ref(cp.ID)})
+}
+
+// Un pas de charge ; renvoie true quand la batterie est pleine et que le
+// drone a libéré son emplacement au profit du premier de la file
+func (env *Environment) charge(dr *Drone, dt float64) bool {
+	cp := &env.ChargingPoints[dr.Station]
+	dr.RemainingEnergy = math.Min(dr.Battery, dr.RemainingEnergy+cp.rate(dr)*dt/3600)
+	dr.RemainingAutonomy = dr.RemainingEnergy * 3600 / dr.Airframe.power(dr.Speed)
+	if dr.RemainingEnergy < dr.Battery {
+		return false
+	}
+	dr.recharge()
+	cp.Charging = slices.DeleteFunc(cp.Charging, func(id int) bool { return id == dr.ID })
+	if len(cp.Queue) > 0 {
+		next := &env.Drones[cp.Queue[0]]
+		cp.Queue = cp.Queue[1:]
+		cp.Charging = append(cp.Charging, next.ID)
+		next.Mode = ModeCharging
+		env.logEvent(Event{Type: EventChargeStarted, Drone: next.ID, ChargingPoint: ref(cp.ID)})
+	}
+	dr.Station = -1
+	return true
+}
@@ -0,0 +1,91 @@
+package main
+
+import (
+	"slices"
+	"testing"
+)
+
+// Station à deux emplacements et quatre drones à vide qui y rentrent
+func testStationEnv() *Environment {
+	env := &Environment{
+		Config:         SimConfig{TimeStep: 0.1},
+		ChargingPoints: newStations([]ChargingPoint{{ID: 0, Pads: 2, ChargeRate: 3600}}),
+	}
+	for i := 0; i < 4; i++ {
+		dr := Drone{ID: i, Speed: 10, Battery: 1, Station: 0, Mode: ModeReturning, Airframe: newAirframe(DroneType{Weight: 1})}
+		env.Drones = append(env.Drones, dr)
+	}
+	return env
+}
+
+func TestDockFillsPadsThenQueues(t *testing.T) {
+	env := testStationEnv()
+	for i := range env.Drones {
+		env.dock(&env.Drones[i])
+	}
+	cp := env.ChargingPoints[0]
+	if !slices.Equal(cp.Charging, []int{0, 1}) || !slices.Equal(cp.Queue, []int{2, 3}) {
+		t.Fatalf("charging %v, queue %v; want [0 1] and [2 3]", cp.Charging, cp.Queue)
+	}
+	for i, want := range []DroneMode{ModeCharging, ModeCharging, ModeWaiting, ModeWaiting} {
+		if env.Drones[i].Mode != want {
+			t.Errorf("drone %d mode %s, want %s", i, env.Drones[i].Mode, want)
+		}
+	}
+}
+
+func TestChargeHandsPadToQueueInFIFOOrder(t *testing.T) {
+	env := testStationEnv()
+	for i := range env.Drones {
+		env.dock(&env.Drones[i])
+	}
+	// 3600 W : 1 Wh en 1 s, soit 4 pas de 0,25 s
+	for step := 0; step < 3; step++ {
+		if env.charge(&env.Drones[1], 0.25) {
+			t.Fatalf("drone 1 full after %d steps", step+1)
+		}
+	}
+	if !env.charge(&env.Drones[1], 0.25) {
+		t.Fatal("drone 1 not full after 1 s")
+	}
+	cp := &env.ChargingPoints[0]
+	if !slices.Equal(cp.Charging, []int{0, 2}) || !slices.Equal(cp.Queue, []int{3}) {
+		t.Fatalf("charging %v, queue %v; want [0 2] and [3]", cp.Charging, cp.Queue)
+	}
+	if env.Drones[2].Mode != ModeCharging || env.Drones[1].Station != -1 {
+		t.Errorf("drone 2 mode %s, drone 1 station %d", env.Drones[2].Mode, env.Drones[1].Station)
+	}
+
+	for !env.charge(&env.Drones[0], 0.25) {
+	}
+	if !slices.Equal(cp.Charging, []int{2, 3}) || len(cp.Queue) != 0 {
+		t.Errorf("charging %v, queue %v; want [2 3] and an empty queue", cp.Charging, cp.Queue)
+	}
+}
+
+func TestChooseStationKeepsEnergyReserve(t *testing.T) {
+	// station proche occupée pour 1000 s, station libre à 1000 m
+	env := &Environment{
+		Config: SimConfig{TimeStep: 0.1},
+		ChargingPoints: newStations([]ChargingPoint{
+			{ID: 0, X: 100, Pads: 1, ChargeRate: 36},
+			{ID: 1, X: 1000, Pads: 1, ChargeRate: 36},
+		}),
+	}
+	frame := newAirframe(DroneType{Weight: 1})
+	env.Drones = []Drone{
+		{ID: 0, Speed: 10, Battery: 10, RemainingEnergy: 3, Station: -1, Mode: ModeSearching, Airframe: frame},
+		{ID: 1, Speed: 10, Battery: 10, Station: 0, Mode: ModeCharging, Airframe: frame},
+	}
+	dr := &env.Drones[0]
+
+	// 1000 m coûtent 2,85 Wh : atteignable sans réserve, la file est évitée
+	if got := env.chooseStation(dr); got != 1 {
+		t.Fatalf("without reserve: station %d, want 1", got)
+	}
+	// avec 20 % de réserve il faut 3,41 Wh : la station lointaine est exclue
+	env.Config.EnergyReserve = 0.2
+	if got := env.chooseStation(dr); got != 0 {
+		t.Errorf("with reserve: station %d, want 0", got)
+	}
+}
@@ -0,0 +1,288 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"runtime"
+)
+
+const defaultMaxSteps = 20000 // sécurité pour les simulations hors-ligne
+
+const usage = `Usage : drones-sim <commande> [options]
+
+Commandes :
+  serve   lance le serveur web de simulation (commande par défaut)
+  train   batch d'entraînement offline, écrit la meilleure policy
+  run     lance une simulation headless jusqu'à la fin
+  eval    évalue une policy sur N graines
+
+"drones-sim <commande> -h" pour les options de chaque commande.
+`
+
+// Point d'entrée de la ligne de commande, retourne le code de sortie
+func runCLI(args []string) int {
+	cmd := "serve"
+	if len(args) > 0 && args[0] != "" && args[0][0] != '-' {
+		cmd, args = args[0], args[1:]
+	}
+
+	var err error
+	switch cmd {
+	case "serve":
+		err = cmdServe(args)
+	case "train":
+		err = cmdTrain(args)
+	case "run":
+		err = cmdRun(args)
+	case "eval":
+		err = cmdEval(args)
+	case "help":
+		fmt.Print(usage)
+		return 0
+	default:
+		fmt.Fprintf(os.Stderr, "commande inconnue %q\n\n%s", cmd, usage)
+		return 2
+	}
+
+	if errors.Is(err, flag.ErrHelp) {
+		return 0
+	}
+	if err != nil {
+		log.Println(err)
+		return 1
+	}
+	return 0
+}
+
+func newFlagSet(name string) *flag.FlagSet {
+	return flag.NewFlagSet(name, flag.ContinueOnError)
+}
+
+// Fichier d'entrée de l'option name, requis si elle a été donnée explicitement
+func inputFlag(fs *flag.FlagSet, name, path string) inputFile {
+	in := inputFile{Path: path}
+	fs.Visit(func(f *flag.Flag) {
+		if f.Name == name {
+			in.Required = true
+		}
+	})
+	return in
+}
+
+func cmdServe(args []string) error {
+	fs := newFlagSet("serve")
+	port := os.Getenv("PORT")
+	if port == "" {
+		port = "8080"
+	}
+	opts := ServeOptions{}
+	fs.StringVar(&opts.Config.Path, "config", "config.json", "fichier de configuration du scénario")
+	fs.StringVar(&opts.Policy.Path, "policy", "best_policy.json", "policy apprise appliquée à la config (vide = aucune)")
+	fs.StringVar(&opts.FrontPath, "front", "", "front de Pareto dont on sert un point (remplace -policy)")
+	fs.StringVar(&opts.Point, "point", "knee", "point du front : index ou knee, max-rescue, min-time, min-energy, min-cost")
+	fs.StringVar(&opts.Port, "port", port, "port HTTP")
+	fs.StringVar(&opts.WebDir, "web", "web", "dossier des fichiers statiques")
+	fs.Int64Var(&opts.Seed, "seed", 0, "graine des simulations (0 = aléatoire)")
+	fs.DurationVar(&opts.IdleTimeout, "idle", defaultIdleTimeout, "inactivité avant suppression d'une session")
+	fs.StringVar(&opts.ReplayDir, "replays", "replays", "dossier des replays enregistrés")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	opts.Config = inputFlag(fs, "config", opts.Config.Path)
+	opts.Policy = inputFlag(fs, "policy", opts.Policy.Path)
+	return runServer(opts)
+}
+
+func cmdTrain(args []string) error {
+	fs := newFlagSet("train")
+	opts := TrainOptions{}
+	fs.StringVar(&opts.Config.Path, "config", "config.json", "fichier de configuration du scénario")
+	fs.StringVar(&opts.OutputPath, "output", "best_policy.json", "fichier de policy écrit en sortie")
+	fs.BoolVar(&opts.Fleet, "fleet", false, "optimise aussi la composition de la flotte sous le budget (catalogue de la config)")
+	fs.StringVar(&opts.ParetoPath, "pareto", "", "front de Pareto écrit en sortie (vide = pareto_front.json à côté de -output)")
+	fs.IntVar(&opts.Workers, "workers", runtime.NumCPU(), "nombre de simulations évaluées en parallèle")
+	fs.Int64Var(&opts.Seed, "seed", 0, "graine maître de l'optimiseur (0 = aléatoire ; graines d'entraînement fixes 1..N)")
+	fs.StringVar(&opts.Optimizer, "optimizer", "random", "optimiseur : random, grid, genetic, cmaes")
+	fs.IntVar(&opts.Budget, "budget", 80, "nombre de combinaisons évaluées")
+	fs.IntVar(&opts.TrainSeeds, "train-seeds", 5, "graines d'entraînement jouées par combinaison")
+	fs.IntVar(&opts.HoldOut, "holdout", 30, "graines held-out pour valider la meilleure policy")
+	fs.StringVar(&opts.Profile, "profile", "", "profil de score (default, first-contact, coverage, economy ; vide = config)")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if err := validateScoringProfile(opts.Profile); err != nil {
+		return err
+	}
+	opts.Config = inputFlag(fs, "config", opts.Config.Path)
+	return RunBatchTraining(opts)
+}
+
+func cmdRun(args []string) error {
+	fs := newFlagSet("run")
+	configPath := fs.String("config", "config.json", "fichier de configuration du scénario")
+	policyPath := fs.String("policy", "best_policy.json", "policy apprise appliquée à la config (vide = aucune)")
+	output := fs.String("output", "", "fichier du rapport (vide = JSON sur la sortie standard)")
+	format := fs.String("format", "json", "format du rapport : json, csv (<output>_survivors.csv, <output>_drones.csv) ou both")
+	seed := fs.Int64("seed", 0, "graine de la simulation (0 = celle de la config, sinon aléatoire)")
+	maxSteps := fs.Int("max-steps", defaultMaxSteps, "nombre maximum de pas de simulation")
+	record := fs.String("record", "", "enregistre le run dans ce replay (.jsonl.gz)")
+	recordEvery := fs.Int("record-every", 1, "un pas enregistré tous les k pas")
+	knowledge := fs.String("knowledge", "", "connaissance des drones : omniscient ou decentralized (vide = config)")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if err := validateKnowledge(KnowledgeMode(*knowledge)); err != nil {
+		return err
+	}
+	switch *format {
+	case "json":
+	case "csv", "both":
+		if *output == "" {
+			return fmt.Errorf("-format %s nécessite -output", *format)
+		}
+	default:
+		return fmt.Errorf("format inconnu %q (json, csv, both)", *format)
+	}
+
+	cfg, err := loadScenario(inputFlag(fs, "config", *configPath), inputFlag(fs, "policy", *policyPath))
+	if err != nil {
+		return err
+	}
+	if *seed != 0 {
+		cfg.Seed = *seed
+	}
+	if *knowledge != "" {
+		cfg.Knowledge = KnowledgeMode(*knowledge)
+	}
+	var env Environment
+	if *record != "" {
+		if env, err = recordSimulation(cfg, *maxSteps, *record, *recordEvery); err != nil {
+			return err
+		}
+	} else {
+		env = runSimulation(cfg, *maxSteps)
+	}
+	report := buildRunReport(env)
+
+	if *format != "json" {
+		if err := writeRunReportCSV(reportBase(*output), report); err != nil {
+			return err
+		}
+	}
+	if *format != "csv" {
+		path := *output
+		if *format == "both" {
+			path = reportBase(*output) + ".json"
+		}
+		return writeJSONOutput(path, report)
+	}
+	return nil
+}
+
+func cmdEval(args []string) error {
+	fs := newFlagSet("eval")
+	configPath := fs.String("config", "config.json", "fichier de configuration du scénario")
+	policyPath := fs.String("policy", "best_policy.json", "policy à évaluer (vide = config seule)")
+	baselinePath := fs.String("baseline", "", "policy de référence à comparer (vide = pas de comparaison)")
+	output := fs.String("output", "", "fichier JSON du rapport d'évaluation (vide = pas d'écriture)")
+	seed := fs.Int64("seed", 1, "première graine d'entraînement (graines seed, seed+1, ...)")
+	numSeeds := fs.Int("seeds", 20, "nombre de graines d'entraînement")
+	holdOut := fs.Int("holdout", 30, "nombre de graines held-out")
+	profile := fs.String("profile", "", "profil de score (default, first-contact, coverage, economy ; vide = config)")
+	workers := fs.Int("workers", runtime.NumCPU(), "nombre de simulations en parallèle")
+	maxSteps := fs.Int("max-steps", defaultMaxSteps, "nombre maximum de pas de simulation")
+	knowledge := fs.String("knowledge", "", "connaissance des drones : omniscient ou decentralized (vide = config)")
+	baselineKnowledge := fs.String("baseline-knowledge", "", "connaissance des drones pour la référence (vide = comme -knowledge)")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *numSeeds <= 0 || *holdOut <= 0 {
+		return fmt.Errorf("-seeds et -holdout doivent être > 0")
+	}
+	if err := validateScoringProfile(*profile); err != nil {
+		return err
+	}
+	for _, k := range []string{*knowledge, *baselineKnowledge} {
+		if err := validateKnowledge(KnowledgeMode(k)); err != nil {
+			return err
+		}
+	}
+	if *baselineKnowledge == "" {
+		*baselineKnowledge = *knowledge
+	}
+
+	sets := fixedSeedSets(*seed, *numSeeds, *holdOut)
+	config := inputFlag(fs, "config", *configPath)
+	cfg, err := loadScenario(config, inputFlag(fs, "policy", *policyPath))
+	if err != nil {
+		return err
+	}
+	if *profile != "" {
+		cfg.Scoring = ScoringConfig{Profile: *profile}
+	}
+	if *knowledge != "" {
+		cfg.Knowledge = KnowledgeMode(*knowledge)
+	}
+
+	type evalOutput struct {
+		Seeds       SeedSets     `json:"seeds"`
+		Train       EvalReport   `json:"train"`
+		HoldOut     EvalReport   `json:"holdOut"`
+		Baseline    *EvalReport  `json:"baseline,omitempty"` // held-out
+		Comparisons []Comparison `json:"comparisons,omitempty"`
+	}
+	out := evalOutput{
+		Seeds:   sets,
+		Train:   evaluatePolicy(cfg, sets.Train, *workers, *maxSteps),
+		HoldOut: evaluatePolicy(cfg, sets.HoldOut, *workers, *maxSteps),
+	}
+	printEvalReport(os.Stdout, "Graines d'entraînement", out.Train)
+	printEvalReport(os.Stdout, "Graines held-out", out.HoldOut)
+
+	if *baselinePath != "" {
+		baseCfg, err := loadScenario(config, inputFile{Path: *baselinePath, Required: true})
+		if err != nil {
+			return err
+		}
+		baseCfg.Scoring = cfg.Scoring
+		if *baselineKnowledge != "" {
+			baseCfg.Knowledge = KnowledgeMode(*baselineKnowledge)
+		}
+		baseline := evaluatePolicy(baseCfg, sets.HoldOut, *workers, *maxSteps)
+		cmp, err := comparePolicies(baseline, out.HoldOut)
+		if err != nil {
+			return err
+		}
+		out.Baseline = &baseline
+		out.Comparisons = cmp
+		printEvalReport(os.Stdout, "Référence, graines held-out", baseline)
+		fmt.Printf("\nComparaison held-out, policy - référence :\n")
+		printComparisons(os.Stdout, cmp)
+	}
+
+	if *output == "" {
+		return nil
+	}
+	return writeJSONOutput(*output, out)
+}
+
+// Écrit v en JSON indenté dans path (sortie standard si vide)
+func writeJSONOutput(path string, v any) error {
+	var w io.Writer = os.Stdout
+	if path != "" {
+		f, err := os.Create(path)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		w = f
+	}
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(v)
+}
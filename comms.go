@@ -0,0 +1,209 @@
+package main
+
+import (
+	"math"
+	"slices"
+)
+
+//
+// ------------------------ Communication entre drones ------------------------
+//
+// Les drones échangent des messages typés par radio : un message émis atteint
+// les drones à portée de l'émetteur après la latence, chaque réception pouvant
+// être perdue. Avec le relais, un drone réémet une fois chaque message reçu
+// tant qu'il n'a pas fait MaxHops sauts. Le récepteur lit ses messages dans
+// Percept et décide dans Deliberate d'y répondre ou non.
+
+type MessageKind string
+
+const (
+	MsgHelpRequest   MessageKind = "help_request"   // demande de renforts sur une zone
+	MsgZoneClaimed   MessageKind = "zone_claimed"   // un drone part en renfort pour une demande
+	MsgSurvivorFound MessageKind = "survivor_found" // survivant sauvé : sa zone est libérée
+)
+
+const defaultMaxHops = 3
+
+// Bloc "comms" de la config
+type CommsConfig struct {
+	Range    float64 `json:"range,omitempty"`    // portée radio (rayonAide par défaut)
+	Latency  float64 `json:"latency,omitempty"`  // délai d'un saut, s
+	LossRate float64 `json:"lossRate,omitempty"` // probabilité de perdre une réception
+	Relay    bool    `json:"relay,omitempty"`    // relais multi-sauts
+	MaxHops  int     `json:"maxHops,omitempty"`  // sauts au plus avec le relais (3 par défaut)
+}
+
+type Message struct {
+	ID       int         `json:"id"`
+	Kind     MessageKind `json:"kind"`
+	From     int         `json:"from"`
+	To       int         `json:"to"` // destinataire, -1 pour une diffusion
+	X        float64     `json:"x"`  // zone concernée
+	Y        float64     `json:"y"`
+	Trace    *int        `json:"trace,omitempty"`
+	Survivor *int        `json:"survivor,omitempty"`
+	Request  int         `json:"request"` // zone_claimed : ID de la demande
+	Hops     int         `json:"hops"`    // sauts déjà faits
+
+	Belief BeliefMap `json:"-"` // carte de couverture de l'émetteur (mode décentralisé)
+}
+
+// Un saut de message en cours vers un récepteur
+type Transmission struct {
+	Msg Message
+	To  int     // drone qui reçoit ce saut
+	At  float64 // instant de réception
+}
+
+func (c CommsConfig) rangeFor(cfg SimConfig) float64 {
+	if c.Range > 0 {
+		return c.Range
+	}
+	return cfg.RayonAide
+}
+
+// Émet un saut du message depuis le drone from vers les drones à portée
+func (d *DroneAgent) transmit(env *Environment, from int, msg Message) {
+	cc := env.Config.Comms
+	src := &env.Drones[from]
+	src.MessagesSent++
+	reach := cc.rangeFor(env.Config)
+	for i := range env.Drones {
+		dr := &env.Drones[i]
+		if i == from || distance(src.X, src.Y, dr.X, dr.Y) > reach {
+			continue
+		}
+		if cc.LossRate > 0 && d.rng.Float64() < cc.LossRate {
+			src.MessagesLost++
+			continue
+		}
+		env.Radio = append(env.Radio, Transmission{Msg: msg, To: i, At: env.Time + cc.Latency})
+	}
+}
+
+// Émet un nouveau message du drone de l'agent (to = -1 pour diffuser)
+func (d *DroneAgent) send(env *Environment, msg Message) {
+	msg.ID = env.NextMessage
+	env.NextMessage++
+	msg.From = d.index
+	if env.Config.Knowledge == KnowledgeDecentralized {
+		msg.Belief = d.belief.clone()
+	}
+	d.seen[msg.ID] = true
+	d.transmit(env, d.index, msg)
+}
+
+// Retire de la radio les messages arrivés pour le drone id
+func (env *Environment) receive(id int) []Message {
+	var in []Message
+	env.Radio = slices.DeleteFunc(env.Radio, func(t Transmission) bool {
+		if t.To != id || t.At > env.Time {
+			return false
+		}
+		in = append(in, t.Msg)
+		return true
+	})
+	return in
+}
+
+// Lit les messages arrivés : chaque message n'est traité qu'une fois, les
+// doublons venus par d'autres relais sont ignorés
+func (d *DroneAgent) readMessages(env *Environment) {
+	d.inbox = d.inbox[:0]
+	cc := env.Config.Comms
+	maxHops := cc.MaxHops
+	if maxHops <= 0 {
+		maxHops = defaultMaxHops
+	}
+	for _, msg := range env.receive(d.index) {
+		if d.seen[msg.ID] {
+			continue
+		}
+		d.seen[msg.ID] = true
+		// toute réception, même destinée à un autre, apporte la carte de l'émetteur
+		if msg.Belief != nil {
+			d.belief.merge(msg.Belief)
+		}
+		if cc.Relay && msg.Hops+1 < maxHops && msg.To != d.index {
+			relayed := msg
+			relayed.Hops++
+			d.transmit(env, d.index, relayed)
+		}
+		if msg.To >= 0 && msg.To != d.index {
+			continue
+		}
+		env.Drones[d.index].MessagesReceived++
+		d.inbox = append(d.inbox, msg)
+	}
+}
+
+// Décide quoi faire des messages reçus : au plus une demande de renfort
+// acceptée, si le drone cherche, est assez proche et que la demande n'a pas
+// déjà assez de renforts (annoncés par zone_claimed)
+func (d *DroneAgent) handleMessages(env *Environment) {
+	cfg := env.Config
+	dr := &env.Drones[d.index]
+	for _, msg := range d.inbox {
+		if msg.Kind == MsgZoneClaimed {
+			d.claims[msg.Request]++
+		}
+	}
+	zoneRadius := cfg.DetectionRadius * cfg.TailleIndice
+	best, bestDist := -1, math.Inf(1)
+	for i, msg := range d.inbox {
+		switch msg.Kind {
+		case MsgSurvivorFound:
+			// on allait aider sur la zone de ce survivant : elle est libérée
+			if dr.Mode == ModeResponding && dr.HasTarget && distance(msg.X, msg.Y, dr.TargetX, dr.TargetY) <= zoneRadius {
+				d.release = true
+			}
+		case MsgHelpRequest:
+			dist := distance(dr.X, dr.Y, msg.X, msg.Y)
+			if dr.Mode != ModeSearching || dist > cfg.RayonAide || d.claims[msg.ID] >= cfg.MaxHelpersPerHit {
+				continue
+			}
+			if dist < bestDist {
+				best, bestDist = i, dist
+			}
+		}
+	}
+	d.accepted = nil
+	if best >= 0 {
+		msg := d.inbox[best]
+		d.accepted = &msg
+	}
+}
+
+// Applique les décisions prises sur les messages, en début d'Act
+func (d *DroneAgent) applyMessages(env *Environment) {
+	dr := &env.Drones[d.index]
+	if d.release {
+		d.release = false
+		dr.HasTarget = false
+		dr.Mode = ModeSearching
+		angle := d.rng.Float64() * 2 * math.Pi
+		dr.Vx = math.Cos(angle) * dr.Speed
+		dr.Vy = math.Sin(angle) * dr.Speed
+	}
+	req := d.accepted
+	d.accepted = nil
+	if req == nil || dr.Mode != ModeSearching {
+		return
+	}
+	d.claims[req.ID]++
+	dr.Mode = ModeResponding
+	dr.TargetX = req.X
+	dr.TargetY = req.Y
+	dr.HasTarget = true
+	env.Drones[req.From].HelpersCalled++
+	env.logEvent(Event{Type: EventHelpAccepted, Drone: dr.ID, Trace: req.Trace, Survivor: req.Survivor, Requester: ref(req.From)})
+	d.send(env, Message{Kind: MsgZoneClaimed, To: -1, X: req.X, Y: req.Y, Request: req.ID})
+}
+
+// Diffuse une demande de renforts sur (x, y) ; les drones qui la reçoivent
+// décident eux-mêmes d'y répondre
+func (d *DroneAgent) requestHelp(env *Environment, x, y float64, trace, survivor *int) {
+	env.Drones[d.index].HelpCalls++
+	env.logEvent(Event{Type: EventHelpCall, Drone: d.index, Trace: trace, Survivor: survivor})
+	d.send(env, Message{Kind: MsgHelpRequest, To: -1, X: x, Y: y, Trace: trace, Survivor: survivor})
+}
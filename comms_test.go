@@ -0,0 +1,57 @@
+package main
+
+import (
+	"math/rand"
+	"testing"
+)
+
+// Trois drones alignés : 1 à 50 m de 0, 2 à 500 m
+func testRadioEnv(comms CommsConfig) *Environment {
+	env := &Environment{Config: SimConfig{Comms: comms}}
+	env.Config.RayonAide = 100
+	for i, x := range []float64{0, 50, 500} {
+		env.Drones = append(env.Drones, Drone{ID: i, X: x})
+	}
+	return env
+}
+
+func TestMessagesReachOnlyDronesInRange(t *testing.T) {
+	env := testRadioEnv(CommsConfig{Latency: 0.5})
+	a := NewDroneAgent(0, &env.Config, rand.New(rand.NewSource(1)))
+	a.send(env, Message{Kind: MsgHelpRequest, To: -1})
+
+	if len(env.receive(1)) != 0 {
+		t.Fatal("message received before the latency")
+	}
+	env.Time = 0.5
+	if in := env.receive(1); len(in) != 1 || in[0].From != 0 {
+		t.Fatalf("drone in range received %+v", in)
+	}
+	if in := env.receive(2); len(in) != 0 {
+		t.Errorf("drone out of range received %+v", in)
+	}
+	if len(env.Radio) != 0 {
+		t.Errorf("%d transmissions left on the radio", len(env.Radio))
+	}
+
+	// la portée explicite remplace rayonAide
+	env = testRadioEnv(CommsConfig{Range: 600})
+	a = NewDroneAgent(0, &env.Config, rand.New(rand.NewSource(1)))
+	a.send(env, Message{Kind: MsgHelpRequest, To: -1})
+	if len(env.receive(1)) != 1 || len(env.receive(2)) != 1 {
+		t.Error("range 600 should reach both drones")
+	}
+}
+
+func TestLostMessagesAreCounted(t *testing.T) {
+	env := testRadioEnv(CommsConfig{Range: 600, LossRate: 1})
+	a := NewDroneAgent(0, &env.Config, rand.New(rand.NewSource(1)))
+	a.send(env, Message{Kind: MsgHelpRequest, To: -1})
+
+	if len(env.Radio) != 0 {
+		t.Errorf("%d transmissions despite lossRate 1", len(env.Radio))
+	}
+	if d := env.Drones[0]; d.MessagesSent != 1 || d.MessagesLost != 2 {
+		t.Errorf("sent %d, lost %d; want 1 and 2", d.MessagesSent, d.MessagesLost)
+	}
+}
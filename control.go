@@ -0,0 +1,283 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+)
+
+//
+// ------------------------ Contrôle du déroulé ------------------------
+//
+
+const (
+	minSpeed      = 0.25
+	maxSpeed      = 50
+	maxSpeedChunk = 50                    // pas par prise de verrou en mode "max"
+	maxSpeedSlice = 40 * time.Millisecond // temps de calcul par tick en mode "max"
+	maxStepBatch  = 100000                // limite de /api/step
+)
+
+// Vitesse de simulation en pas par tick de 50 ms (1 = temps réel historique).
+// En JSON : un nombre, ou "max" pour SpeedMax.
+type SimSpeed float64
+
+const SpeedMax SimSpeed = -1
+
+func (v SimSpeed) MarshalJSON() ([]byte, error) {
+	if v == SpeedMax {
+		return []byte(`"max"`), nil
+	}
+	return json.Marshal(float64(v))
+}
+
+func (v *SimSpeed) UnmarshalJSON(b []byte) error {
+	var name string
+	if json.Unmarshal(b, &name) == nil {
+		if name != "max" {
+			return fmt.Errorf("vitesse inconnue %q", name)
+		}
+		*v = SpeedMax
+		return nil
+	}
+	var f float64
+	if err := json.Unmarshal(b, &f); err != nil {
+		return err
+	}
+	*v = SimSpeed(f)
+	return nil
+}
+
+func (v SimSpeed) valid() bool {
+	return v == SpeedMax || (v >= minSpeed && v <= maxSpeed)
+}
+
+// Condition d'arrêt : prochain survivant trouvé, ou instant atteint
+type RunUntil struct {
+	Condition string  `json:"condition"`      // "survivor" ou "time"
+	Time      float64 `json:"time,omitempty"` // instant visé pour "time"
+	saved     int     // survivants sauvés au lancement
+}
+
+func (u *RunUntil) met(env *Environment) bool {
+	switch u.Condition {
+	case "survivor":
+		return savedCount(env) > u.saved
+	case "time":
+		return env.Time >= u.Time
+	}
+	return true
+}
+
+func savedCount(env *Environment) int {
+	n := 0
+	for _, sv := range env.Survivors {
+		if sv.Saved {
+			n++
+		}
+	}
+	return n
+}
+
+// Un tick de Run : avance selon la vitesse courante
+func (s *Simulation) tick() {
+	s.mu.Lock()
+	if !s.running {
+		s.mu.Unlock()
+		return
+	}
+	if s.speed == SpeedMax {
+		s.mu.Unlock()
+		deadline := time.Now().Add(maxSpeedSlice)
+		for time.Now().Before(deadline) {
+			if s.advance(maxSpeedChunk) < maxSpeedChunk {
+				return
+			}
+		}
+		return
+	}
+	s.stepCredit += float64(s.speed)
+	n := int(s.stepCredit)
+	s.stepCredit -= float64(n)
+	s.mu.Unlock()
+	s.advance(n)
+}
+
+// Exécute jusqu'à n pas ; s'arrête à la fin de la simulation ou quand la
+// condition de run-until est remplie (la simulation passe alors en pause).
+func (s *Simulation) advance(n int) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.advanceLocked(n)
+}
+
+// Avance de n pas seulement si la simulation est en pause ; le test et les
+// pas se font sous le même verrou (ok faux si elle tourne)
+func (s *Simulation) stepPaused(n int) (done int, ok bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.running {
+		return 0, false
+	}
+	return s.advanceLocked(n), true
+}
+
+func (s *Simulation) advanceLocked(n int) int {
+	done := 0
+	for done < n && !s.env.Finished {
+		s.stepLocked()
+		done++
+		if s.until != nil && s.until.met(&s.env) {
+			s.running = false
+			s.until = nil
+			break
+		}
+	}
+	if s.env.Finished {
+		s.until = nil
+	}
+	return done
+}
+
+func (s *Simulation) SetSpeed(v SimSpeed) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.speed = v
+	s.stepCredit = 0
+}
+
+// État des contrôles renvoyé par /api/speed, /api/step et /api/run-until
+type ControlState struct {
+	Running   bool      `json:"running"`
+	Speed     SimSpeed  `json:"speed"`
+	Time      float64   `json:"time"`
+	Finished  bool      `json:"finished"`
+	Until     *RunUntil `json:"until,omitempty"`
+	Steps     int       `json:"steps,omitempty"`     // pas exécutés par /api/step
+	Recording string    `json:"recording,omitempty"` // replay en cours d'enregistrement
+}
+
+func (s *Simulation) controlState() ControlState {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return ControlState{
+		Running:   s.running,
+		Speed:     s.speed,
+		Time:      s.env.Time,
+		Finished:  s.env.Finished,
+		Until:     s.until,
+		Recording: s.recordingName(),
+	}
+}
+
+// Décode le corps JSON d'une requête de contrôle (corps vide accepté)
+func decodeBody(w http.ResponseWriter, r *http.Request, v any) bool {
+	if r.Body == nil {
+		return true
+	}
+	defer r.Body.Close()
+	if err := json.NewDecoder(r.Body).Decode(v); err != nil && err != io.EOF {
+		writeAPIError(w, http.StatusBadRequest, APIError{Code: "invalid_body", Message: err.Error()})
+		return false
+	}
+	return true
+}
+
+// GET : vitesse courante ; POST {"speed": 0.25..50 | "max"} : change la vitesse
+func handleSpeed(w http.ResponseWriter, r *http.Request, sess *Session) {
+	sim := sess.sim
+	if r.Method == http.MethodPost {
+		var req struct {
+			Speed SimSpeed `json:"speed"`
+		}
+		if !decodeBody(w, r, &req) {
+			return
+		}
+		if !req.Speed.valid() {
+			writeAPIError(w, http.StatusBadRequest, APIError{
+				Code:    "invalid_speed",
+				Message: fmt.Sprintf("vitesse entre %gx et %gx, ou \"max\"", minSpeed, float64(maxSpeed)),
+				Details: map[string]any{"speed": req.Speed},
+			})
+			return
+		}
+		sim.SetSpeed(req.Speed)
+	}
+	writeJSON(w, sim.controlState())
+}
+
+// POST {"n": N} : exécute exactement N pas, simulation en pause
+func handleStep(w http.ResponseWriter, r *http.Request, sess *Session) {
+	sim := sess.sim
+	req := struct {
+		N int `json:"n"`
+	}{N: 1}
+	if !decodeBody(w, r, &req) {
+		return
+	}
+	if req.N < 1 || req.N > maxStepBatch {
+		writeAPIError(w, http.StatusBadRequest, APIError{
+			Code:    "invalid_steps",
+			Message: fmt.Sprintf("n doit être entre 1 et %d", maxStepBatch),
+			Details: map[string]any{"n": req.N},
+		})
+		return
+	}
+	done, ok := sim.stepPaused(req.N)
+	if !ok {
+		writeAPIError(w, http.StatusConflict, APIError{Code: "not_paused", Message: "mettre la simulation en pause avant d'avancer pas à pas"})
+		return
+	}
+	st := sim.controlState()
+	st.Steps = done
+	writeJSON(w, st)
+}
+
+// POST {"condition": "survivor"} ou {"condition": "time", "time": T} :
+// relance la simulation et la met en pause quand la condition est remplie
+func handleRunUntil(w http.ResponseWriter, r *http.Request, sess *Session) {
+	var req RunUntil
+	if !decodeBody(w, r, &req) {
+		return
+	}
+	if apiErr := sess.sim.runUntil(req); apiErr != nil {
+		status := http.StatusBadRequest
+		if apiErr.Code == "finished" {
+			status = http.StatusConflict
+		}
+		writeAPIError(w, status, *apiErr)
+		return
+	}
+	writeJSON(w, sess.sim.controlState())
+}
+
+// Arme la condition d'arrêt et relance la simulation
+func (s *Simulation) runUntil(req RunUntil) *APIError {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.env.Finished {
+		return &APIError{Code: "finished", Message: "la simulation est terminée"}
+	}
+	switch req.Condition {
+	case "survivor":
+		req.saved = savedCount(&s.env)
+	case "time":
+		if req.Time <= s.env.Time {
+			return &APIError{
+				Code:    "invalid_condition",
+				Message: fmt.Sprintf("instant %.1f déjà atteint (t=%.1f)", req.Time, s.env.Time),
+				Details: map[string]any{"time": req.Time, "now": s.env.Time},
+			}
+		}
+	default:
+		return &APIError{
+			Code:    "invalid_condition",
+			Message: fmt.Sprintf("condition inconnue %q (survivor ou time)", req.Condition),
+		}
+	}
+	s.until = &req
+	s.running = true
+	return nil
+}
@@ -0,0 +1,93 @@
+package main
+
+import "math"
+
+//
+// ------------------------ Modèle énergétique ------------------------
+//
+// Puissance d'un multirotor (unités SI, 1 unité de carte = 1 m) :
+//   - induite : (m·g)^1.5 / sqrt(2·ρ·A), le coût de la sustentation (vol stationnaire) ;
+//   - traînée : ½·ρ·CdS·v³ à la vitesse air v ;
+//   - accélération : hausse d'énergie cinétique ½·m·(v² - v0²) sur le pas ;
+// divisée par le rendement de propulsion, plus l'avionique. La batterie est en Wh.
+
+const (
+	gravity              = 9.81  // m/s²
+	airDensity           = 1.225 // kg/m³
+	propulsionEfficiency = 0.7
+	avionicsPower        = 5.0 // W
+	defaultEnergyReserve = 0.1 // marge sur l'énergie prévue pour rejoindre un point de charge
+)
+
+// Caractéristiques physiques d'un drone
+type Airframe struct {
+	Mass      float64 `json:"mass"`      // kg
+	RotorArea float64 `json:"rotorArea"` // surface balayée par les rotors, m²
+	DragArea  float64 `json:"dragArea"`  // Cd·S, m²
+}
+
+// Cellule du drone ; RotorArea et DragArea nuls prennent des valeurs typiques pour la masse
+func newAirframe(dt DroneType) Airframe {
+	a := Airframe{Mass: dt.Weight, RotorArea: dt.RotorArea, DragArea: dt.DragArea}
+	if a.Mass <= 0 {
+		a.Mass = 1
+	}
+	if a.RotorArea <= 0 {
+		a.RotorArea = 0.1 * a.Mass
+	}
+	if a.DragArea <= 0 {
+		a.DragArea = 0.01 * math.Pow(a.Mass, 2.0/3)
+	}
+	return a
+}
+
+// Puissance électrique en vol stabilisé à la vitesse air v (W)
+func (a Airframe) power(v float64) float64 {
+	induced := math.Pow(a.Mass*gravity, 1.5) / math.Sqrt(2*airDensity*a.RotorArea)
+	drag := 0.5 * airDensity * a.DragArea * v * v * v
+	return (induced+drag)/propulsionEfficiency + avionicsPower
+}
+
+// Énergie (Wh) d'un pas de dt secondes à la vitesse air v, partant de la vitesse air v0
+func (a Airframe) stepEnergy(v0, v, dt float64) float64 {
+	joules := a.power(v) * dt
+	if dKE := 0.5 * a.Mass * (v*v - v0*v0); dKE > 0 {
+		joules += dKE / propulsionEfficiency
+	}
+	return joules / 3600
+}
+
+// Énergie prévue (Wh) pour parcourir dist à la vitesse de croisière du drone
+func (dr *Drone) energyToReach(dist float64) float64 {
+	if dr.Speed <= 0 {
+		return 0
+	}
+	return dr.Airframe.power(dr.Speed) * dist / dr.Speed / 3600
+}
+
+// Initialise la batterie : capacité donnée, sinon de quoi voler Autonomy secondes en croisière
+func (dr *Drone) initBattery(batteryWh float64) {
+	cruise := dr.Airframe.power(dr.Speed)
+	if batteryWh > 0 {
+		dr.Battery = batteryWh
+		dr.Autonomy = batteryWh * 3600 / cruise
+	} else {
+		dr.Battery = cruise * dr.Autonomy / 3600
+	}
+	dr.recharge()
+}
+
+func (dr *Drone) recharge() {
+	dr.RemainingEnergy = dr.Battery
+	dr.RemainingAutonomy = dr.Autonomy
+}
+
+// Consomme l'énergie d'un pas à la vitesse air v ; l'autonomie affichée est
+// le temps de croisière que permet l'énergie restante
+func (dr *Drone) consume(v, dt float64) {
+	e := math.Min(dr.Airframe.stepEnergy(dr.airspeed, v, dt), dr.RemainingEnergy)
+	dr.airspeed = v
+	dr.EnergyUsed += e
+	dr.RemainingEnergy -= e
+	dr.RemainingAutonomy = dr.RemainingEnergy * 3600 / dr.Airframe.power(dr.Speed)
+}
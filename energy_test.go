@@ -0,0 +1,70 @@
+package main
+
+import (
+	"math"
+	"testing"
+)
+
+func TestAirframePower(t *testing.T) {
+	a := newAirframe(DroneType{Weight: 1}) // rotors 0,1 m², Cd·S 0,01 m²
+	if a.RotorArea != 0.1 || a.DragArea != 0.01 {
+		t.Fatalf("default airframe = %+v", a)
+	}
+	// stationnaire : (m·g)^1.5 / sqrt(2·ρ·A) / rendement + avionique
+	if got := a.power(0); math.Abs(got-93.679) > 1e-3 {
+		t.Errorf("hover power = %.3f W, want 93.679", got)
+	}
+	// à 10 m/s la traînée ajoute ½·ρ·CdS·v³ = 6,125 W avant rendement
+	if got := a.power(10); math.Abs(got-102.429) > 1e-3 {
+		t.Errorf("power at 10 m/s = %.3f W, want 102.429", got)
+	}
+	heavy := newAirframe(DroneType{Weight: 5})
+	if heavy.power(0) <= a.power(0) {
+		t.Error("a heavier drone should need more hover power")
+	}
+}
+
+func TestStepEnergy(t *testing.T) {
+	a := newAirframe(DroneType{Weight: 1})
+	// vitesse tenue : puissance x durée
+	if got, want := a.stepEnergy(10, 10, 1), a.power(10)/3600; math.Abs(got-want) > 1e-12 {
+		t.Errorf("cruise step = %v Wh, want %v", got, want)
+	}
+	// accélération de 0 à 10 m/s : + ½·m·v² / rendement
+	if got := a.stepEnergy(0, 10, 1); math.Abs(got-0.0482939) > 1e-6 {
+		t.Errorf("accelerating step = %.7f Wh, want 0.0482939", got)
+	}
+	// le freinage ne rend pas d'énergie
+	if got, want := a.stepEnergy(10, 5, 1), a.power(5)/3600; math.Abs(got-want) > 1e-12 {
+		t.Errorf("braking step = %v Wh, want %v", got, want)
+	}
+}
+
+func TestReturnEnergyFollowsPlannedRoute(t *testing.T) {
+	cfg := defaultConfig()
+	cfg.Width, cfg.Height = 1000, 800
+	cfg.Zones = normalizeZones([]Zone{{Kind: ZoneObstacle, Polygon: []Point{{480, 150}, {520, 150}, {520, 650}, {480, 650}}}})
+	env := &Environment{
+		Config:         cfg,
+		planner:        newPlanner(cfg),
+		ChargingPoints: newStations([]ChargingPoint{{X: 700, Y: 400}}),
+	}
+	dr := &Drone{X: 300, Y: 400, Speed: 10, Airframe: newAirframe(DroneType{Weight: 1})}
+
+	straight := dr.energyToReach(400)
+	got := env.returnEnergy(dr)
+	// détour par un coin du mur, environ 2 x hypot(200, 250) ≈ 640 m
+	if got < dr.energyToReach(620) || got > dr.energyToReach(700) {
+		t.Errorf("return energy %.3f Wh, straight line %.3f Wh; want the detour of about 640 m", got, straight)
+	}
+	// la même estimation que le chemin de plan, à la cellule de départ près
+	route, _ := env.planner.plan(dr.X, dr.Y, 700, 400)
+	length, x, y := 0.0, dr.X, dr.Y
+	for _, pt := range route {
+		length += distance(x, y, pt.X, pt.Y)
+		x, y = pt.X, pt.Y
+	}
+	if want := dr.energyToReach(length); math.Abs(got-want) > 0.02*want {
+		t.Errorf("return energy %.3f Wh, planned route %.3f Wh", got, want)
+	}
+}
@@ -0,0 +1,337 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"sort"
+)
+
+// Les graines held-out commencent ici : elles ne recoupent jamais celles d'entraînement
+const holdOutSeedBase = 1_000_000
+
+// Jeux de graines fixes : entraînement et held-out (jamais vues pendant l'entraînement)
+type SeedSets struct {
+	Train   []int64 `json:"train"`
+	HoldOut []int64 `json:"holdOut"`
+}
+
+// Graines trainBase, trainBase+1, ... et holdOutSeedBase+1, ...
+func fixedSeedSets(trainBase int64, nTrain, nHoldOut int) SeedSets {
+	sets := SeedSets{
+		Train:   make([]int64, nTrain),
+		HoldOut: make([]int64, nHoldOut),
+	}
+	for i := range sets.Train {
+		sets.Train[i] = trainBase + int64(i)
+	}
+	for i := range sets.HoldOut {
+		sets.HoldOut[i] = holdOutSeedBase + 1 + int64(i)
+	}
+	return sets
+}
+
+// Statistiques descriptives d'une métrique sur un jeu de graines
+type MetricSummary struct {
+	N      int     `json:"n"`
+	Mean   float64 `json:"mean"`
+	StdDev float64 `json:"stdDev"`
+	Median float64 `json:"median"`
+	P5     float64 `json:"p5"`
+	P25    float64 `json:"p25"`
+	P75    float64 `json:"p75"`
+	P95    float64 `json:"p95"`
+	CILow  float64 `json:"ciLow"` // intervalle de confiance à 95% de la moyenne
+	CIHigh float64 `json:"ciHigh"`
+}
+
+// Résultat d'une policy sur un jeu de graines
+type EvalReport struct {
+	Seeds        []int64       `json:"seeds"`
+	Score        MetricSummary `json:"score"`
+	TimeToRescue MetricSummary `json:"timeToRescue"`
+	RescueRate   MetricSummary `json:"rescueRate"`
+	Runs         []SimStats    `json:"runs"`
+	Scores       []float64     `json:"scores"`     // score de chaque run
+	Unfinished   int           `json:"unfinished"` // runs sans tous les survivants sauvés, hors timeToRescue
+}
+
+// Métriques du i-ème run d'un rapport, dans l'ordre des champs de EvalReport
+var evalMetrics = []struct {
+	name  string
+	value func(r EvalReport, i int) float64
+}{
+	{"score", func(r EvalReport, i int) float64 { return r.Scores[i] }},
+	{"timeToRescue", func(r EvalReport, i int) float64 { return timeToRescue(r.Runs[i]) }},
+	{"rescueRate", func(r EvalReport, i int) float64 { return rescueRate(r.Runs[i]) }},
+}
+
+// Instant du dernier sauvetage. NaN si des survivants restent à sauver : la
+// fin d'un tel run n'est que la limite de pas, elle n'entre pas dans la métrique.
+func timeToRescue(st SimStats) float64 {
+	if st.SavedSurvivors < st.TotalSurvivors {
+		return math.NaN()
+	}
+	return st.LastFindTime
+}
+
+func rescueRate(st SimStats) float64 {
+	if st.TotalSurvivors == 0 {
+		return 0
+	}
+	return float64(st.SavedSurvivors) / float64(st.TotalSurvivors)
+}
+
+// Évalue une config sur un jeu de graines
+func evaluatePolicy(cfg SimConfig, seeds []int64, workers, maxSteps int) EvalReport {
+	runs := runSeeds(cfg, seeds, workers, maxSteps)
+	r := EvalReport{Seeds: seeds, Runs: runs, Scores: make([]float64, len(runs))}
+	for i, st := range runs {
+		r.Scores[i] = scoreStats(st, cfg.Scoring)
+		if st.SavedSurvivors < st.TotalSurvivors {
+			r.Unfinished++
+		}
+	}
+	r.Score = summarize(metricValues(r, 0))
+	r.TimeToRescue = summarize(metricValues(r, 1))
+	r.RescueRate = summarize(metricValues(r, 2))
+	return r
+}
+
+// Valeurs de la k-ième métrique de evalMetrics, runs sans valeur (NaN) exclus
+func metricValues(r EvalReport, k int) []float64 {
+	var out []float64
+	for i := range r.Runs {
+		if v := evalMetrics[k].value(r, i); !math.IsNaN(v) {
+			out = append(out, v)
+		}
+	}
+	return out
+}
+
+func summarize(values []float64) MetricSummary {
+	n := len(values)
+	if n == 0 {
+		return MetricSummary{}
+	}
+	sorted := append([]float64(nil), values...)
+	sort.Float64s(sorted)
+
+	mean, std := meanStd(values)
+	half := 0.0
+	if n > 1 {
+		half = studentTQuantile(0.975, float64(n-1)) * std / math.Sqrt(float64(n))
+	}
+	return MetricSummary{
+		N:      n,
+		Mean:   mean,
+		StdDev: std,
+		Median: percentile(sorted, 50),
+		P5:     percentile(sorted, 5),
+		P25:    percentile(sorted, 25),
+		P75:    percentile(sorted, 75),
+		P95:    percentile(sorted, 95),
+		CILow:  mean - half,
+		CIHigh: mean + half,
+	}
+}
+
+// Moyenne et écart-type (non biaisé)
+func meanStd(values []float64) (float64, float64) {
+	n := float64(len(values))
+	var sum float64
+	for _, v := range values {
+		sum += v
+	}
+	mean := sum / n
+	if len(values) < 2 {
+		return mean, 0
+	}
+	var ss float64
+	for _, v := range values {
+		ss += (v - mean) * (v - mean)
+	}
+	return mean, math.Sqrt(ss / (n - 1))
+}
+
+// Percentile par interpolation linéaire sur des valeurs triées
+func percentile(sorted []float64, p float64) float64 {
+	if len(sorted) == 1 {
+		return sorted[0]
+	}
+	pos := p / 100 * float64(len(sorted)-1)
+	lo := int(math.Floor(pos))
+	hi := int(math.Ceil(pos))
+	return sorted[lo] + (pos-float64(lo))*(sorted[hi]-sorted[lo])
+}
+
+//
+// ------------------------ Comparaison de policies ------------------------
+//
+
+// Différence appariée (B - A) d'une métrique sur les mêmes graines
+type Comparison struct {
+	Metric      string  `json:"metric"`
+	N           int     `json:"n"` // paires comparées (runs où la métrique existe pour les deux)
+	MeanDiff    float64 `json:"meanDiff"`
+	CILow       float64 `json:"ciLow"`
+	CIHigh      float64 `json:"ciHigh"`
+	PValue      float64 `json:"pValue"`
+	Significant bool    `json:"significant"` // p < 0.05
+}
+
+// Test t apparié de b contre a pour chaque métrique (mêmes graines, même ordre)
+func comparePolicies(a, b EvalReport) ([]Comparison, error) {
+	if len(a.Runs) != len(b.Runs) || len(a.Runs) < 2 {
+		return nil, fmt.Errorf("comparaison impossible : %d et %d runs", len(a.Runs), len(b.Runs))
+	}
+	for i := range a.Seeds {
+		if a.Seeds[i] != b.Seeds[i] {
+			return nil, fmt.Errorf("comparaison impossible : graines différentes")
+		}
+	}
+
+	var out []Comparison
+	for _, m := range evalMetrics {
+		var diffs []float64
+		for i := range a.Runs {
+			if d := m.value(b, i) - m.value(a, i); !math.IsNaN(d) {
+				diffs = append(diffs, d)
+			}
+		}
+		if len(diffs) < 2 {
+			out = append(out, Comparison{Metric: m.name, N: len(diffs), PValue: 1})
+			continue
+		}
+		n := float64(len(diffs))
+		mean, std := meanStd(diffs)
+		df := n - 1
+		half := studentTQuantile(0.975, df) * std / math.Sqrt(n)
+
+		p := 1.0
+		if std > 0 {
+			t := mean / (std / math.Sqrt(n))
+			p = 2 * (1 - studentTCDF(math.Abs(t), df))
+		} else if mean != 0 {
+			p = 0
+		}
+		out = append(out, Comparison{
+			Metric:      m.name,
+			N:           len(diffs),
+			MeanDiff:    mean,
+			CILow:       mean - half,
+			CIHigh:      mean + half,
+			PValue:      p,
+			Significant: p < 0.05,
+		})
+	}
+	return out, nil
+}
+
+//
+// ------------------------ Affichage ------------------------
+//
+
+func printEvalReport(w io.Writer, title string, r EvalReport) {
+	fmt.Fprintf(w, "\n=== %s (%d graines) ===\n", title, len(r.Seeds))
+	fmt.Fprintf(w, "%-14s %10s %10s %10s %10s %10s %10s %10s %23s\n",
+		"métrique", "moyenne", "écart-type", "médiane", "p5", "p25", "p75", "p95", "IC 95%")
+	for _, row := range []struct {
+		name string
+		m    MetricSummary
+	}{{"score", r.Score}, {"timeToRescue", r.TimeToRescue}, {"rescueRate", r.RescueRate}} {
+		m := row.m
+		fmt.Fprintf(w, "%-14s %10.3f %10.3f %10.3f %10.3f %10.3f %10.3f %10.3f [%10.3f, %10.3f]\n",
+			row.name, m.Mean, m.StdDev, m.Median, m.P5, m.P25, m.P75, m.P95, m.CILow, m.CIHigh)
+	}
+	if r.Unfinished > 0 {
+		fmt.Fprintf(w, "%d runs sans tous les survivants sauvés, exclus de timeToRescue\n", r.Unfinished)
+	}
+}
+
+func printComparisons(w io.Writer, cmp []Comparison) {
+	for _, c := range cmp {
+		verdict := "significatif"
+		if !c.Significant {
+			verdict = "NON significatif"
+		}
+		fmt.Fprintf(w, "%-14s diff=%+.3f IC95%%=[%+.3f, %+.3f] p=%.4f n=%d -> %s\n",
+			c.Metric, c.MeanDiff, c.CILow, c.CIHigh, c.PValue, c.N, verdict)
+	}
+}
+
+//
+// ------------------------ Loi de Student ------------------------
+//
+
+// Fonction de répartition de la loi de Student à df degrés de liberté
+func studentTCDF(t, df float64) float64 {
+	x := df / (df + t*t)
+	tail := 0.5 * regIncBeta(df/2, 0.5, x)
+	if t >= 0 {
+		return 1 - tail
+	}
+	return tail
+}
+
+// Quantile de la loi de Student, par dichotomie sur la fonction de répartition
+func studentTQuantile(p, df float64) float64 {
+	lo, hi := -1000.0, 1000.0
+	for i := 0; i < 200; i++ {
+		mid := (lo + hi) / 2
+		if studentTCDF(mid, df) < p {
+			lo = mid
+		} else {
+			hi = mid
+		}
+	}
+	return (lo + hi) / 2
+}
+
+// Fonction bêta incomplète régularisée I_x(a, b) (fraction continue de Lentz)
+func regIncBeta(a, b, x float64) float64 {
+	if x <= 0 {
+		return 0
+	}
+	if x >= 1 {
+		return 1
+	}
+	lga, _ := math.Lgamma(a)
+	lgb, _ := math.Lgamma(b)
+	lgab, _ := math.Lgamma(a + b)
+	front := math.Exp(lgab - lga - lgb + a*math.Log(x) + b*math.Log(1-x))
+
+	// symétrie pour assurer la convergence
+	if x > (a+1)/(a+b+2) {
+		return 1 - regIncBeta(b, a, 1-x)
+	}
+
+	const tiny = 1e-30
+	f, c, d := 1.0, 1.0, 0.0
+	for i := 0; i <= 300; i++ {
+		m := float64(i / 2)
+		var num float64
+		switch {
+		case i == 0:
+			num = 1
+		case i%2 == 0:
+			num = m * (b - m) * x / ((a + 2*m - 1) * (a + 2*m))
+		default:
+			num = -(a + m) * (a + b + m) * x / ((a + 2*m) * (a + 2*m + 1))
+		}
+		d = 1 + num*d
+		if math.Abs(d) < tiny {
+			d = tiny
+		}
+		d = 1 / d
+		c = 1 + num/c
+		if math.Abs(c) < tiny {
+			c = tiny
+		}
+		f *= c * d
+		if math.Abs(1-c*d) < 1e-12 {
+			break
+		}
+	}
+	return front * (f - 1) / a
+}
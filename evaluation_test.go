@@ -0,0 +1,66 @@
+package main
+
+import (
+	"math"
+	"testing"
+)
+
+func TestStudentTQuantile(t *testing.T) {
+	// valeurs des tables de la loi de Student
+	for _, tc := range []struct{ p, df, want float64 }{
+		{0.975, 1, 12.706},
+		{0.975, 5, 2.571},
+		{0.975, 10, 2.228},
+		{0.975, 30, 2.042},
+		{0.95, 10, 1.812},
+		{0.995, 20, 2.845},
+	} {
+		if got := studentTQuantile(tc.p, tc.df); math.Abs(got-tc.want) > 1e-3 {
+			t.Errorf("studentTQuantile(%v, %v) = %.4f, want %.3f", tc.p, tc.df, got, tc.want)
+		}
+	}
+}
+
+// Rapport sur les graines 1..n : scores donnés, tous les survivants sauvés à lastFind
+func testReport(scores, lastFind []float64) EvalReport {
+	r := EvalReport{Scores: scores}
+	for i := range scores {
+		r.Seeds = append(r.Seeds, int64(i+1))
+		r.Runs = append(r.Runs, SimStats{TotalSurvivors: 2, SavedSurvivors: 2, LastFindTime: lastFind[i]})
+	}
+	return r
+}
+
+func TestComparePoliciesPaired(t *testing.T) {
+	// b = a + 1 à peu de bruit près : l'écart est net une fois apparié,
+	// alors que la dispersion entre graines est bien plus grande
+	a := testReport([]float64{10, 20, 30, 40, 50}, []float64{100, 200, 300, 400, 500})
+	b := testReport([]float64{11.1, 20.9, 31.05, 40.95, 51}, []float64{100, 200, 300, 400, 500})
+	cmp, err := comparePolicies(a, b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	score := cmp[0]
+	if math.Abs(score.MeanDiff-1) > 1e-9 || !score.Significant || score.CILow <= 0 {
+		t.Errorf("score comparison = %+v, want a significant +1", score)
+	}
+	// temps identiques : aucune différence
+	if ttr := cmp[1]; ttr.MeanDiff != 0 || ttr.Significant || ttr.N != 5 {
+		t.Errorf("timeToRescue comparison = %+v", ttr)
+	}
+
+	// un run inachevé sort de la comparaison de timeToRescue
+	b.Runs[2].SavedSurvivors = 1
+	cmp, err = comparePolicies(a, b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cmp[1].N != 4 {
+		t.Errorf("timeToRescue compared %d pairs, want 4", cmp[1].N)
+	}
+
+	b.Seeds[0] = 99
+	if _, err := comparePolicies(a, b); err == nil {
+		t.Error("reports on different seeds were compared")
+	}
+}
@@ -0,0 +1,98 @@
+package main
+
+import (
+	"net/http"
+	"strconv"
+)
+
+//
+// ------------------------ Journal d'événements ------------------------
+//
+
+type EventType string
+
+const (
+	EventTraceActivated    EventType = "trace_activated"    // un drone a détecté une trace
+	EventHelpCall          EventType = "help_call"          // demande de renforts diffusée
+	EventHelpAccepted      EventType = "help_accepted"      // un drone part en renfort sur une demande reçue
+	EventReturning         EventType = "returning"          // retour vers un point de charge
+	EventRechargeCompleted EventType = "recharge_completed" // autonomie rechargée
+	EventSurvivorSaved     EventType = "survivor_saved"     // survivant trouvé
+	EventResponderTimeout  EventType = "responder_timeout"  // renfort abandonné après DureeEngagement
+	EventSurvivorDetected  EventType = "survivor_detected"  // survivant détecté, en attente de confirmation
+	EventFalsePositive     EventType = "false_positive"     // fausse alerte du capteur (trace fantôme)
+	EventChargeQueued      EventType = "charge_queued"      // arrivé à une station pleine, en file d'attente
+	EventChargeStarted     EventType = "charge_started"     // posé sur un emplacement de charge
+)
+
+// Événement de mission ; seuls les champs utiles au type sont renseignés
+type Event struct {
+	Seq           int       `json:"seq"`  // position dans le journal
+	Time          float64   `json:"time"` // fin du pas où l'événement a lieu
+	Type          EventType `json:"type"`
+	Drone         int       `json:"drone"`
+	Trace         *int      `json:"trace,omitempty"`
+	Survivor      *int      `json:"survivor,omitempty"`
+	Requester     *int      `json:"requester,omitempty"`     // help_accepted : drone qui a demandé de l'aide
+	ChargingPoint *int      `json:"chargingPoint,omitempty"` // returning, charge_* : station
+	Autonomy      float64   `json:"autonomy,omitempty"`      // returning : autonomie restante (s)
+	Energy        float64   `json:"energy,omitempty"`        // returning : énergie restante (Wh)
+}
+
+func ref(i int) *int { return &i }
+
+// Ajoute un événement daté de la fin du pas courant
+func (e *Environment) logEvent(ev Event) {
+	ev.Seq = len(e.Events)
+	ev.Time = e.Time + e.Config.TimeStep
+	e.Events = append(e.Events, ev)
+}
+
+// Événements à partir de since, éventuellement filtrés par type
+func eventsSince(events []Event, since int, types map[EventType]bool) []Event {
+	out := []Event{}
+	if since < 0 || since > len(events) {
+		since = 0
+	}
+	for _, ev := range events[since:] {
+		if len(types) == 0 || types[ev.Type] {
+			out = append(out, ev)
+		}
+	}
+	return out
+}
+
+// Réponse de /api/sims/{id}/events
+type EventsResponse struct {
+	Gen    int     `json:"gen"`  // change à chaque reset : le curseur repart alors de 0
+	Next   int     `json:"next"` // valeur de since pour la requête suivante
+	Events []Event `json:"events"`
+}
+
+// GET /api/sims/{id}/events?since=N&type=help_call&type=...
+func handleEvents(w http.ResponseWriter, r *http.Request, sess *Session) {
+	q := r.URL.Query()
+	since := 0
+	if v := q.Get("since"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 0 {
+			writeAPIError(w, http.StatusBadRequest, APIError{Code: "invalid_since", Message: "since doit être un entier >= 0"})
+			return
+		}
+		since = n
+	}
+	types := map[EventType]bool{}
+	for _, t := range q["type"] {
+		types[EventType(t)] = true
+	}
+
+	s := sess.sim
+	s.mu.RLock()
+	resp := EventsResponse{
+		Gen:    s.gen,
+		Next:   len(s.env.Events),
+		Events: eventsSince(s.env.Events, since, types),
+	}
+	s.mu.RUnlock()
+	writeJSON(w, resp)
+}
@@ -0,0 +1,162 @@
+package main
+
+import (
+	"log"
+	"math"
+	"math/rand"
+)
+
+//
+// ------------------------ Obstacles et zones interdites ------------------------
+//
+
+type ZoneKind string
+
+const (
+	ZoneObstacle ZoneKind = "obstacle" // bâtiment, relief... : infranchissable
+	ZoneNoFly    ZoneKind = "nofly"    // zone interdite de survol
+)
+
+type Point struct {
+	X float64 `json:"x"`
+	Y float64 `json:"y"`
+}
+
+// Zone polygonale que les drones ne peuvent pas traverser
+type Zone struct {
+	ID      int      `json:"id"`
+	Name    string   `json:"name,omitempty"`
+	Kind    ZoneKind `json:"kind"`
+	Polygon []Point  `json:"polygon"` // sommets dans l'ordre, polygone fermé implicitement
+}
+
+// Zones valides de la config (au moins 3 sommets), numérotées dans l'ordre
+func normalizeZones(zones []Zone) []Zone {
+	out := []Zone{}
+	for _, z := range zones {
+		if len(z.Polygon) < 3 {
+			log.Printf("Zone %q ignored: %d vertices", z.Name, len(z.Polygon))
+			continue
+		}
+		if z.Kind == "" {
+			z.Kind = ZoneObstacle
+		}
+		z.ID = len(out)
+		out = append(out, z)
+	}
+	return out
+}
+
+// Point dans le polygone (lancer de rayon)
+func (z Zone) contains(x, y float64) bool {
+	in := false
+	n := len(z.Polygon)
+	for i, j := 0, n-1; i < n; j, i = i, i+1 {
+		a, b := z.Polygon[i], z.Polygon[j]
+		if (a.Y > y) != (b.Y > y) && x < (b.X-a.X)*(y-a.Y)/(b.Y-a.Y)+a.X {
+			in = !in
+		}
+	}
+	return in
+}
+
+// Le segment [a, b] coupe-t-il un bord du polygone ?
+func (z Zone) crosses(ax, ay, bx, by float64) bool {
+	n := len(z.Polygon)
+	for i, j := 0, n-1; i < n; j, i = i, i+1 {
+		p, q := z.Polygon[j], z.Polygon[i]
+		if segmentsIntersect(ax, ay, bx, by, p.X, p.Y, q.X, q.Y) {
+			return true
+		}
+	}
+	return false
+}
+
+func segmentsIntersect(ax, ay, bx, by, cx, cy, dx, dy float64) bool {
+	d1 := cross(cx, cy, dx, dy, ax, ay)
+	d2 := cross(cx, cy, dx, dy, bx, by)
+	d3 := cross(ax, ay, bx, by, cx, cy)
+	d4 := cross(ax, ay, bx, by, dx, dy)
+	return ((d1 > 0) != (d2 > 0)) && ((d3 > 0) != (d4 > 0)) && d1 != 0 && d2 != 0 && d3 != 0 && d4 != 0
+}
+
+// Produit vectoriel (b - a) x (p - a)
+func cross(ax, ay, bx, by, px, py float64) float64 {
+	return (bx-ax)*(py-ay) - (by-ay)*(px-ax)
+}
+
+// Point à l'intérieur d'une des zones
+func insideZones(zones []Zone, x, y float64) bool {
+	for _, z := range zones {
+		if z.contains(x, y) {
+			return true
+		}
+	}
+	return false
+}
+
+// Le déplacement de a vers b entre-t-il dans une zone ? Un drone déjà dans
+// une zone (mal placé par la config) peut toujours en sortir.
+func (e *Environment) segmentBlocked(ax, ay, bx, by float64) bool {
+	for _, z := range e.Zones {
+		if z.contains(ax, ay) {
+			continue
+		}
+		if z.contains(bx, by) || z.crosses(ax, ay, bx, by) {
+			return true
+		}
+	}
+	return false
+}
+
+// Pas d'évitement : on tourne la vitesse par pas de 15° de part et d'autre
+// de la direction voulue jusqu'à trouver un déplacement libre.
+const steerStep = math.Pi / 12
+
+// Oriente la vitesse du drone pour qu'il contourne les zones au lieu d'y entrer,
+// le déplacement tenant compte du vent (wx, wy).
+// Renvoie false si aucune direction n'est libre (le drone reste sur place).
+func steerAroundZones(env *Environment, dr *Drone, wx, wy, dt float64, rng *rand.Rand) bool {
+	if len(env.Zones) == 0 {
+		return true
+	}
+	blocked := func(vx, vy float64) bool {
+		gx, gy := groundVelocity(vx, vy, wx, wy)
+		return env.segmentBlocked(dr.X, dr.Y, dr.X+gx*dt, dr.Y+gy*dt)
+	}
+	if !blocked(dr.Vx, dr.Vy) {
+		return true
+	}
+	speed := math.Hypot(dr.Vx, dr.Vy)
+	if speed == 0 {
+		return true
+	}
+	heading := math.Atan2(dr.Vy, dr.Vx)
+	first := 1.0
+	if rng.Float64() < 0.5 {
+		first = -1
+	}
+	for k := 1; k <= 12; k++ {
+		for _, sign := range []float64{first, -first} {
+			a := heading + sign*float64(k)*steerStep
+			vx, vy := math.Cos(a)*speed, math.Sin(a)*speed
+			if !blocked(vx, vy) {
+				dr.Vx, dr.Vy = vx, vy
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// Tire un point hors des zones (au plus maxTries essais, sinon le dernier tirage)
+func sampleOutsideZones(zones []Zone, maxTries int, draw func() (float64, float64)) (float64, float64, bool) {
+	var x, y float64
+	for try := 0; try < maxTries; try++ {
+		x, y = draw()
+		if !insideZones(zones, x, y) {
+			return x, y, true
+		}
+	}
+	return x, y, false
+}
@@ -0,0 +1,36 @@
+package main
+
+import "testing"
+
+func TestZoneContains(t *testing.T) {
+	// L concave : le coin (150, 50) est hors du polygone
+	l := Zone{Polygon: []Point{{0, 0}, {100, 0}, {100, 100}, {200, 100}, {200, 200}, {0, 200}}}
+	for _, tc := range []struct {
+		x, y float64
+		want bool
+	}{
+		{50, 50, true},
+		{150, 150, true},
+		{150, 50, false}, // dans l'encoche
+		{-10, 100, false},
+		{250, 150, false},
+		{100, 250, false},
+	} {
+		if got := l.contains(tc.x, tc.y); got != tc.want {
+			t.Errorf("contains(%v, %v) = %v, want %v", tc.x, tc.y, got, tc.want)
+		}
+	}
+}
+
+func TestZoneCrosses(t *testing.T) {
+	square := Zone{Polygon: []Point{{100, 100}, {200, 100}, {200, 200}, {100, 200}}}
+	if !square.crosses(0, 150, 300, 150) {
+		t.Error("segment through the square not detected")
+	}
+	if square.crosses(0, 50, 300, 50) {
+		t.Error("segment passing above the square detected")
+	}
+	if square.crosses(120, 120, 180, 180) {
+		t.Error("segment inside the square crosses no edge")
+	}
+}
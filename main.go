@@ -3,6 +3,8 @@ package main
 import (
 	"context"
 	"encoding/json"
+	"errors"
+	"fmt"
 	"log"
 	"math"
 	"math/rand"
@@ -24,6 +26,8 @@ const (
 	ModeResponding DroneMode = "responding"
 	ModeHovering   DroneMode = "hovering"
 	ModeReturning  DroneMode = "returning"
+	ModeWaiting    DroneMode = "waiting"  // posé, dans la file d'une station
+	ModeCharging   DroneMode = "charging" // posé, en charge sur un emplacement
 )
 
 type Drone struct {
@@ -37,6 +41,8 @@ type Drone struct {
 	TargetY   float64   `json:"targetY"`
 	HasTarget bool      `json:"hasTarget"`
 	FoundID   int       `json:"foundID"`
+	Station   int       `json:"station"` // station visée ou occupée (-1 si aucune)
+	Route     []Point   `json:"route"`   // points de passage restants vers la cible (renfort, retour)
 
 	RespondTimer float64 `json:"respondTimer"`
 
@@ -46,14 +52,45 @@ type Drone struct {
 	Autonomy          float64 `json:"autonomy"`
 	RemainingAutonomy float64 `json:"remainingAutonomy"`
 	DetectionRadius   float64 `json:"detectionRadius"`
+	Type              string  `json:"type"`
+
+	Sensor SensorModel `json:"sensor"`
+
+	// énergie : Autonomy et RemainingAutonomy sont des secondes de vol en croisière
+	Airframe        Airframe `json:"airframe"`
+	Battery         float64  `json:"battery"`         // capacité en Wh
+	RemainingEnergy float64  `json:"remainingEnergy"` // Wh
+	airspeed        float64  // vitesse air du pas précédent
+
+	// compteurs pour les rapports de run
+	DistanceFlown float64               `json:"distanceFlown"`
+	EnergyUsed    float64               `json:"energyUsed"` // Wh
+	Recharges     int                   `json:"recharges"`
+	HelpCalls     int                   `json:"helpCalls"`     // appels à l'aide émis
+	HelpersCalled int                   `json:"helpersCalled"` // drones mobilisés par ces appels
+	ModeTime      map[DroneMode]float64 `json:"modeTime"`      // temps passé dans chaque mode
+
+	FalsePositives   int `json:"falsePositives"`   // fausses alertes émises
+	MissedDetections int `json:"missedDetections"` // tirages de détection ratés sur une cible à portée
+
+	MessagesSent     int `json:"messagesSent"`     // émissions radio, relais compris
+	MessagesReceived int `json:"messagesReceived"` // messages reçus et lus
+	MessagesLost     int `json:"messagesLost"`     // réceptions perdues sur ses émissions
 }
 
 type Survivor struct {
-	ID     int     `json:"id"`
-	X      float64 `json:"x"`
-	Y      float64 `json:"y"`
-	Saved  bool    `json:"saved"`
-	Radius float64 `json:"radius"`
+	ID      int     `json:"id"`
+	X       float64 `json:"x"`
+	Y       float64 `json:"y"`
+	Saved   bool    `json:"saved"`
+	Radius  float64 `json:"radius"`
+	FoundAt float64 `json:"foundAt"` // instant de la découverte (confirmée)
+	FoundBy int     `json:"foundBy"` // drone découvreur (-1 si pas trouvé)
+
+	// première détection, avant confirmation (requireConfirmation)
+	Detected   bool    `json:"detected"`
+	DetectedAt float64 `json:"detectedAt"`
+	DetectedBy int     `json:"detectedBy"` // -1 si pas détecté
 }
 
 type Trace struct {
@@ -64,44 +101,106 @@ type Trace struct {
 	Consumed   bool    `json:"consumed"`
 	SurvivorID int     `json:"survivorId"` // -1 si aucune
 	Activated  bool    `json:"-"`          // interne : renfort déjà appelé ou pas
+
+	// fausse alerte d'un capteur, levée quand un autre drone vient vérifier
+	Spurious   bool `json:"spurious,omitempty"`
+	ReportedBy int  `json:"-"`
 }
 
 type ChargingPoint struct {
-	ID int     `json:"id"`
-	X  float64 `json:"x"`
-	Y  float64 `json:"y"`
+	ID         int     `json:"id"`
+	X          float64 `json:"x"`
+	Y          float64 `json:"y"`
+	Pads       int     `json:"pads"`       // emplacements de charge (2 par défaut)
+	ChargeRate float64 `json:"chargeRate"` // W par emplacement (0 = charge complète en 10 s)
+
+	// état de la station pendant la simulation
+	Charging []int `json:"charging,omitempty"` // drones en charge
+	Queue    []int `json:"queue,omitempty"`    // file d'attente FIFO
 }
 
 // type of drones, loaded from JSON
 type DroneType struct {
 	Name            string  `json:"name"`
+	Label           string  `json:"label,omitempty"` // nom affiché dans l'interface
 	Count           int     `json:"count"`
 	Speed           float64 `json:"speed"`
 	Weight          float64 `json:"weight"`
-	Autonomy        float64 `json:"autonomy"`        // total range
+	Autonomy        float64 `json:"autonomy"`        // secondes de vol en croisière
 	DetectionRadius float64 `json:"detectionRadius"` // per-type detection radius
+	Price           float64 `json:"price"`           // prix unitaire en euros
+
+	Sensor SensorModel `json:"sensor"` // modèle de capteur (parfait par défaut)
+
+	// modèle énergétique (Weight est la masse en kg)
+	BatteryWh float64 `json:"batteryWh,omitempty"` // capacité ; 0 = de quoi voler Autonomy secondes en croisière
+	RotorArea float64 `json:"rotorArea,omitempty"` // m² ; 0 = 0,1 m² par kg
+	DragArea  float64 `json:"dragArea,omitempty"`  // Cd·S en m² ; 0 = 0,01·masse^(2/3)
+}
+
+// Paramètres de politique entraînables (config.json, best_policy.json, /api/reset)
+type PolicyParams struct {
+	RayonAide        float64 `json:"rayonAide"`
+	MaxHelpersPerHit int     `json:"maxHelpersPerHit"`
+	TailleIndice     float64 `json:"tailleIndice"`
+	TauxExploration  float64 `json:"tauxExploration"`
+	DureeEngagement  float64 `json:"dureeEngagement"`
+}
+
+// Surcharge les paramètres avec ceux de o qui sont renseignés (> 0)
+func (p *PolicyParams) Merge(o PolicyParams) {
+	if o.RayonAide > 0 {
+		p.RayonAide = o.RayonAide
+	}
+	if o.MaxHelpersPerHit > 0 {
+		p.MaxHelpersPerHit = o.MaxHelpersPerHit
+	}
+	if o.TailleIndice > 0 {
+		p.TailleIndice = o.TailleIndice
+	}
+	if o.TauxExploration > 0 {
+		p.TauxExploration = o.TauxExploration
+	}
+	if o.DureeEngagement > 0 {
+		p.DureeEngagement = o.DureeEngagement
+	}
 }
 
 type SimConfig struct {
-	Width            float64         `json:"width"`
-	Height           float64         `json:"height"`
-	NumDrones        int             `json:"numDrones"`
-	NumSurvivors     int             `json:"numSurvivors"`
-	NumTraces        int             `json:"numTraces"`
-	DroneSpeed       float64         `json:"droneSpeed"`      // default speed if no per-type speed
-	DetectionRadius  float64         `json:"detectionRadius"` // default per-drone detection radius
-	rayonAide        float64         `json:"rayonAide"`
-	MaxHelpersPerHit int             `json:"maxHelpersPerHit"`
-	TimeStep         float64         `json:"timeStep"`
-	DroneTypes       []DroneType     `json:"droneTypes"` // heterogeneous drone types
-	ChargingPoints   []ChargingPoint `json:"chargingPoints"`
-	BaseX            float64         `json:"baseX"` // base position X
-	BaseY            float64         `json:"baseY"` // base position Y
-
-	// paramètres entraînables
-	tailleIndice    float64 `json:"tailleIndice"`
-	tauxExploration float64 `json:"tauxExploration"`
-	dureeEngagement float64 `json:"dureeEngagement"`
+	Width           float64         `json:"width"`
+	Height          float64         `json:"height"`
+	NumDrones       int             `json:"numDrones"`
+	NumSurvivors    int             `json:"numSurvivors"`
+	NumTraces       int             `json:"numTraces"`
+	DroneSpeed      float64         `json:"droneSpeed"`      // default speed if no per-type speed
+	DetectionRadius float64         `json:"detectionRadius"` // default per-drone detection radius
+	TimeStep        float64         `json:"timeStep"`
+	DroneTypes      []DroneType     `json:"droneTypes"` // heterogeneous drone types
+	ChargingPoints  []ChargingPoint `json:"chargingPoints"`
+	BaseX           float64         `json:"baseX"`   // base position X
+	BaseY           float64         `json:"baseY"`   // base position Y
+	Seed            int64           `json:"seed"`    // graine du RNG (0 = aléatoire)
+	Scoring         ScoringConfig   `json:"scoring"` // fonction objectif de l'entraînement
+	Catalog         []DroneType     `json:"catalog"` // modèles de drones achetables (Count : quantité proposée par défaut)
+	Budget          float64         `json:"budget"`  // budget flotte en euros
+
+	// obstacles et zones interdites (polygones)
+	Zones []Zone `json:"zones,omitempty"`
+	// types de terrain, qui réduisent la probabilité de détection
+	Terrain TerrainConfig `json:"terrain"`
+	// un survivant détecté doit être confirmé (second drone ou capteur qui confirme)
+	RequireConfirmation bool `json:"requireConfirmation"`
+	// marge sur l'énergie prévue pour rejoindre un point de charge (0,1 par défaut)
+	EnergyReserve float64 `json:"energyReserve"`
+	// vent uniforme, en rafales ou champ lu d'un fichier
+	Wind WindConfig `json:"wind"`
+	// radio entre drones : portée, latence, pertes, relais
+	Comms CommsConfig `json:"comms"`
+	// connaissance de la couverture : omniscient (heatmap globale) ou decentralized
+	Knowledge KnowledgeMode `json:"knowledge"`
+
+	// paramètres entraînables (champs à plat dans le JSON)
+	PolicyParams
 }
 
 // Statistiques globales de la simulation
@@ -113,6 +212,27 @@ type SimStats struct {
 	Traces         int     `json:"traces"`
 	TracesConsumed int     `json:"tracesConsumed"`
 	Finished       bool    `json:"finished"`
+	Seed           int64   `json:"seed"`
+
+	FirstFindTime     float64 `json:"firstFindTime"`     // première découverte (0 si aucune)
+	LastFindTime      float64 `json:"lastFindTime"`      // dernière découverte (0 si aucune)
+	EnergyConsumed    float64 `json:"energyConsumed"`    // énergie consommée par toute la flotte (Wh)
+	Recharges         int     `json:"recharges"`         // recharges de toute la flotte
+	FleetCost         float64 `json:"fleetCost"`         // coût de la flotte en euros
+	UnvisitedFraction float64 `json:"unvisitedFraction"` // part des cellules de la heatmap jamais survolées
+
+	FalsePositives        int     `json:"falsePositives"`        // fausses alertes des capteurs
+	MissedDetections      int     `json:"missedDetections"`      // tirages de détection ratés
+	PendingConfirmations  int     `json:"pendingConfirmations"`  // survivants détectés mais pas confirmés
+	MeanConfirmationDelay float64 `json:"meanConfirmationDelay"` // délai moyen entre détection et confirmation
+
+	QueueTime     float64 `json:"queueTime"`     // temps passé dans les files des stations (toute la flotte)
+	ChargingTime  float64 `json:"chargingTime"`  // temps passé en charge (toute la flotte)
+	MeanQueueWait float64 `json:"meanQueueWait"` // attente moyenne par recharge
+
+	MessagesSent     int `json:"messagesSent"`     // émissions radio de la flotte
+	MessagesReceived int `json:"messagesReceived"` // messages reçus
+	MessagesLost     int `json:"messagesLost"`     // réceptions perdues
 }
 
 type Environment struct {
@@ -120,11 +240,21 @@ type Environment struct {
 	Drones         []Drone         `json:"drones"`
 	Survivors      []Survivor      `json:"survivors"`
 	Traces         []Trace         `json:"traces"`
+	Alerts         []Trace         `json:"alerts"` // fausses alertes des capteurs, hors des stats de traces
 	ChargingPoints []ChargingPoint `json:"chargingPoints"`
+	Zones          []Zone          `json:"zones"`
+	Terrain        *Terrain        `json:"terrain,omitempty"`
+	Wind           Point           `json:"wind"` // vent au centre de la carte (m/s)
 	Time           float64         `json:"time"`
 	Finished       bool            `json:"finished"`
 	Stats          SimStats        `json:"stats"`
 	Heatmap        [][]float64     `json:"heatmap"`
+	Events         []Event         `json:"-"` // journal de mission (/api/sims/{id}/events)
+	Radio          []Transmission  `json:"-"` // messages en transit entre drones
+	NextMessage    int             `json:"-"` // ID du prochain message émis
+	BaseBelief     BeliefMap       `json:"-"` // carte de couverture de la base (mode décentralisé)
+	planner        *Planner        // grille d'occupation des zones, partagée par les copies
+	wind           *Wind           // modèle de vent, partagé par les copies
 }
 
 // Interface agent
@@ -139,13 +269,27 @@ type Agent interface {
 type DroneAgent struct {
 	index         int
 	cfg           *SimConfig
+	rng           *rand.Rand // RNG propre à la simulation
 	lastPerceived *Environment
+
+	// messagerie : messages lus ce pas et décisions prises dessus
+	inbox    []Message
+	seen     map[int]bool // messages déjà reçus (relais en double)
+	claims   map[int]int  // renforts annoncés par demande d'aide
+	accepted *Message     // demande d'aide acceptée, appliquée dans Act
+	release  bool         // zone du renfort libérée par un survivor_found
+
+	belief BeliefMap // couverture connue du drone (mode décentralisé)
 }
 
-func NewDroneAgent(index int, cfg *SimConfig) *DroneAgent {
+func NewDroneAgent(index int, cfg *SimConfig, rng *rand.Rand) *DroneAgent {
 	return &DroneAgent{
-		index: index,
-		cfg:   cfg,
+		index:  index,
+		cfg:    cfg,
+		rng:    rng,
+		seen:   map[int]bool{},
+		claims: map[int]int{},
+		belief: newBeliefMap(cfg),
 	}
 }
 
@@ -155,9 +299,16 @@ func (d *DroneAgent) Start() {}
 
 func (d *DroneAgent) Percept(env *Environment) {
 	d.lastPerceived = env
+	dr := &env.Drones[d.index]
+	d.belief.observe(dr.X, dr.Y)
+	d.readMessages(env)
 }
 
-func (d *DroneAgent) Deliberate() {}
+func (d *DroneAgent) Deliberate() {
+	if d.lastPerceived != nil {
+		d.handleMessages(d.lastPerceived)
+	}
+}
 
 func (d *DroneAgent) Act(env *Environment) {
 	if env == nil || d.lastPerceived == nil {
@@ -165,9 +316,10 @@ func (d *DroneAgent) Act(env *Environment) {
 	}
 	cfg := env.Config
 	dr := &env.Drones[d.index]
+	d.applyMessages(env)
 
 	// Rayon de zone de recherche autour d'une trace
-	zoneRadius := cfg.DetectionRadius * cfg.tailleIndice
+	zoneRadius := cfg.DetectionRadius * cfg.TailleIndice
 	if zoneRadius <= 0 {
 		zoneRadius = cfg.DetectionRadius
 		if zoneRadius <= 0 {
@@ -177,7 +329,8 @@ func (d *DroneAgent) Act(env *Environment) {
 
 	// Si le drone est assigné à une zone (HasTarget = true),
 	// on vérifie s'il reste un survivant non sauvé dans cette zone.
-	if dr.HasTarget {
+	// Un renfort encore en route ne l'apprend que par survivor_found (ou le timeout).
+	if dr.HasTarget && dr.Mode != ModeResponding {
 		aliveInZone := false
 		for i := range env.Survivors {
 			s := &env.Survivors[i]
@@ -189,29 +342,31 @@ func (d *DroneAgent) Act(env *Environment) {
 				break
 			}
 		}
+		// une fausse alerte retient les renforts tant que personne n'est venu vérifier
+		for _, tr := range env.Alerts {
+			if !tr.Consumed && distance(tr.X, tr.Y, dr.TargetX, dr.TargetY) <= zoneRadius {
+				aliveInZone = true
+				break
+			}
+		}
 		if !aliveInZone {
 			// plus de survivant dans cette zone : on libère le drone
 			dr.HasTarget = false
-			if dr.Mode == ModeResponding {
-				dr.Mode = ModeSearching
-				angle := rand.Float64() * 2 * math.Pi
-				dr.Vx = math.Cos(angle) * dr.Speed
-				dr.Vy = math.Sin(angle) * dr.Speed
-			}
 		}
 	}
 
 	// 0) Timeout renfort paramétrable pour éviter les blocages
-	dureeEngagement := cfg.dureeEngagement
+	dureeEngagement := cfg.DureeEngagement
 	if dureeEngagement <= 0 {
 		dureeEngagement = 8.0
 	}
 	if dr.Mode == ModeResponding {
 		dr.RespondTimer += cfg.TimeStep
 		if dr.RespondTimer > dureeEngagement {
+			env.logEvent(Event{Type: EventResponderTimeout, Drone: dr.ID})
 			dr.Mode = ModeSearching
 			dr.HasTarget = false
-			angle := rand.Float64() * 2 * math.Pi
+			angle := d.rng.Float64() * 2 * math.Pi
 			dr.Vx = math.Cos(angle) * dr.Speed
 			dr.Vy = math.Sin(angle) * dr.Speed
 		}
@@ -219,23 +374,44 @@ func (d *DroneAgent) Act(env *Environment) {
 		dr.RespondTimer = 0
 	}
 
-	// Mode Hovering : ne bouge plus
+	wx, wy := env.wind.at(dr.X, dr.Y, env.Time)
+
+	// Mode Hovering : ne bouge plus (il tient sa position face au vent)
 	if dr.Mode == ModeHovering {
 		dr.Vx, dr.Vy = 0, 0
+		dr.consume(math.Hypot(wx, wy), cfg.TimeStep)
 		return
 	}
 
-	// check distance avec le point de charge le plus proche
-	nearestX, nearestY := findNearestChargingPoint(dr.X, dr.Y, env.ChargingPoints)
-	distToNearest := distance(dr.X, dr.Y, nearestX, nearestY)
+	// Drone posé sur une station : il attend son tour ou charge
+	switch dr.Mode {
+	case ModeWaiting:
+		return
+	case ModeCharging:
+		if env.charge(dr, cfg.TimeStep) {
+			d.syncWithBase(env)
+			dr.Recharges++
+			env.logEvent(Event{Type: EventRechargeCompleted, Drone: dr.ID})
+			dr.Mode = ModeSearching
+			//  direction random
+			angle := d.rng.Float64() * 2 * math.Pi
+			dr.Vx = math.Cos(angle) * dr.Speed
+			dr.Vy = math.Sin(angle) * dr.Speed
+		}
+		return
+	}
 
-	// si autonomie <= 1.1 * temps estimé pour atteindre le point de charge, retour (sécurité)
-	timeToReach := distToNearest / dr.Speed
-	if dr.Mode != ModeReturning && dr.RemainingAutonomy <= 1.1*timeToReach {
+	// si énergie restante <= énergie prévue pour atteindre le point de charge + marge, retour (sécurité) ;
+	// l'énergie prévue suit le chemin autour des zones et tient compte du vent de face
+	if dr.Mode != ModeReturning && dr.RemainingEnergy <= env.returnEnergy(dr)*(1+cfg.EnergyReserve) {
+		// la station visée tient compte de l'attente prévue, pas seulement de la distance
+		dr.Station = env.chooseStation(dr)
+		cp := env.ChargingPoints[dr.Station]
+		env.logEvent(Event{Type: EventReturning, Drone: dr.ID, ChargingPoint: ref(cp.ID), Autonomy: dr.RemainingAutonomy, Energy: dr.RemainingEnergy})
 		dr.Mode = ModeReturning
 		dr.HasTarget = true
-		dr.TargetX = nearestX
-		dr.TargetY = nearestY
+		dr.TargetX = cp.X
+		dr.TargetY = cp.Y
 	}
 
 	dt := cfg.TimeStep
@@ -243,16 +419,18 @@ func (d *DroneAgent) Act(env *Environment) {
 	// 3) Mouvement selon le mode
 	switch dr.Mode {
 	case ModeSearching:
-		tauxExploration := cfg.tauxExploration
+		tauxExploration := cfg.TauxExploration
 		if tauxExploration <= 0 {
 			tauxExploration = 0.02
 		}
 
-		if rand.Float64() < tauxExploration {
+		if d.rng.Float64() < tauxExploration {
 
-			bestAngle := rand.Float64() * 2 * math.Pi
+			bestAngle := d.rng.Float64() * 2 * math.Pi
 			bestScore := math.Inf(-1)
 
+			// heatmap globale ou carte propre au drone, selon cfg.Knowledge
+			coverage := d.coverage(env)
 			for k := 0; k < 8; k++ {
 				angle := float64(k) * math.Pi / 4
 				nx := dr.X + math.Cos(angle)*30
@@ -262,10 +440,10 @@ func (d *DroneAgent) Act(env *Environment) {
 				iy := int(ny / 20)
 
 				if ix >= 0 && iy >= 0 &&
-					ix < len(env.Heatmap) && iy < len(env.Heatmap[0]) {
+					ix < len(coverage) && iy < len(coverage[0]) {
 
-					h := env.Heatmap[ix][iy]
-					score := -h + rand.Float64()*0.1
+					h := coverage[ix][iy]
+					score := -h + d.rng.Float64()*0.1
 
 					if score > bestScore {
 						bestScore = score
@@ -280,45 +458,49 @@ func (d *DroneAgent) Act(env *Environment) {
 
 	case ModeResponding:
 		if dr.HasTarget {
-			dx := dr.TargetX - dr.X
-			dy := dr.TargetY - dr.Y
-			dist := math.Hypot(dx, dy)
-			// Tant qu'on est loin de la zone, on se rapproche
+			dist := distance(dr.X, dr.Y, dr.TargetX, dr.TargetY)
+			// Tant qu'on est loin de la zone, on se rapproche (en contournant les obstacles)
 			if dist > zoneRadius*0.8 {
-				dr.Vx = dx / dist * dr.Speed
-				dr.Vy = dy / dist * dr.Speed
+				dr.flyToward(nextWaypoint(env, dr))
 			} else {
 				// Une fois DANS la zone, on passe en recherche locale
 				dr.Mode = ModeSearching
-				angle := rand.Float64() * 2 * math.Pi
+				angle := d.rng.Float64() * 2 * math.Pi
 				dr.Vx = math.Cos(angle) * dr.Speed
 				dr.Vy = math.Sin(angle) * dr.Speed
 			}
 		}
 
 	case ModeReturning:
-		// retour au pt de charge le plus proche
-		dx := dr.TargetX - dr.X
-		dy := dr.TargetY - dr.Y
-		dist := math.Hypot(dx, dy)
-		if dist > 5 {
-			dr.Vx = dx / dist * dr.Speed
-			dr.Vy = dy / dist * dr.Speed
+		// retour à la station choisie
+		dist := distance(dr.X, dr.Y, dr.TargetX, dr.TargetY)
+		if dist > dockDistance {
+			dr.flyToward(nextWaypoint(env, dr))
 		} else {
-			// arrivé près du point de charge, reset auto et recherche
-			dr.RemainingAutonomy = dr.Autonomy
-			dr.Mode = ModeSearching
-			dr.HasTarget = false
-			//  direction random
-			angle := rand.Float64() * 2 * math.Pi
-			dr.Vx = math.Cos(angle) * dr.Speed
-			dr.Vy = math.Sin(angle) * dr.Speed
+			// arrivé à la station : on se pose (emplacement libre ou file d'attente)
+			env.dock(dr)
+			d.syncWithBase(env)
+			return
 		}
 	}
 
-	// 4) Mise à jour position
-	dr.X += dr.Vx * dt
-	dr.Y += dr.Vy * dt
+	// le chemin planifié ne sert qu'aux renforts et aux retours
+	if dr.Mode != ModeResponding && dr.Mode != ModeReturning {
+		dr.Route = nil
+	}
+
+	// 4) Mise à jour position (contournement des obstacles et zones interdites)
+	// (Vx, Vy) est la vitesse air visée ; le vent donne la vitesse sol
+	prevX, prevY := dr.X, dr.Y
+	if steerAroundZones(env, dr, wx, wy, dt, d.rng) {
+		gx, gy := groundVelocity(dr.Vx, dr.Vy, wx, wy)
+		dr.X += gx * dt
+		dr.Y += gy * dt
+	} else {
+		// coincé : on fait demi-tour sans bouger et on replanifie au prochain pas
+		dr.Vx, dr.Vy = -dr.Vx, -dr.Vy
+		dr.Route = nil
+	}
 
 	// 5) Bords
 	if dr.X < 0 {
@@ -351,19 +533,23 @@ func (d *DroneAgent) Act(env *Environment) {
 				dr.Y = dr.TargetY + dy/dist*zoneRadius
 			} else {
 				// au cas où (exactement au centre), petit déplacement aléatoire
-				angle := rand.Float64() * 2 * math.Pi
+				angle := d.rng.Float64() * 2 * math.Pi
 				dr.X = dr.TargetX + math.Cos(angle)*zoneRadius*0.5
 				dr.Y = dr.TargetY + math.Sin(angle)*zoneRadius*0.5
 			}
 		}
 	}
 
-	// 6) Consommation d'autonomie (temps écoulé sur ce pas)
-	dr.RemainingAutonomy -= dt
-	if dr.RemainingAutonomy < 0 {
-		dr.RemainingAutonomy = 0
+	// Les rebonds et le rappel dans le cercle ne doivent pas faire entrer dans une zone
+	if env.segmentBlocked(prevX, prevY, dr.X, dr.Y) {
+		dr.X, dr.Y = prevX, prevY
 	}
 
+	dr.DistanceFlown += distance(prevX, prevY, dr.X, dr.Y)
+
+	// 6) Consommation d'énergie selon la vitesse air réellement tenue sur ce pas
+	dr.consume(math.Hypot((dr.X-prevX)/dt-wx, (dr.Y-prevY)/dt-wy), dt)
+
 	// 7) Si en mode retour, on ignore traces et survivants
 	if dr.Mode == ModeReturning {
 		return
@@ -377,33 +563,62 @@ func (d *DroneAgent) Act(env *Environment) {
 		}
 	}
 
+	// Fausses alertes du capteur
+	d.falsePositive(env, dr, detRadius)
+
+	// Fausses alertes levées quand un autre drone passe vérifier
+	for ai := range env.Alerts {
+		tr := &env.Alerts[ai]
+		if !tr.Consumed && dr.ID != tr.ReportedBy && distance(dr.X, dr.Y, tr.X, tr.Y) <= detRadius {
+			tr.Consumed = true
+		}
+	}
+
 	// 8) Traces
 	for ti := range env.Traces {
 		tr := &env.Traces[ti]
 		if tr.Consumed {
 			continue
 		}
-		if distance(dr.X, dr.Y, tr.X, tr.Y) <= detRadius+tr.Radius {
+		dist := distance(dr.X, dr.Y, tr.X, tr.Y)
+		// On appelle les renforts UNE SEULE FOIS ; capteur et terrain peuvent rater la trace sur ce pas
+		if !tr.Activated && dist <= detRadius+tr.Radius && env.detects(dr, tr.X, tr.Y, dist, detRadius+tr.Radius, d.rng) {
 			// La trace disparaît ici après détection
-			// On appelle les renforts UNE SEULE FOIS
-			if !tr.Activated {
-				tr.Activated = true
-				callNeighborsForHelp(env, d.index, ti)
-			}
+			tr.Activated = true
+			env.logEvent(Event{Type: EventTraceActivated, Drone: dr.ID, Trace: ref(tr.ID)})
+			d.requestHelp(env, tr.X, tr.Y, ref(tr.ID), nil)
 		}
 	}
 
-	// 9) Survivants
 	// 9) Survivants
 	for si := range env.Survivors {
 		s := &env.Survivors[si]
 		if s.Saved {
 			continue
 		}
-		if distance(dr.X, dr.Y, s.X, s.Y) <= detRadius+s.Radius {
-			// Le drone détecte le survivant
+		dist := distance(dr.X, dr.Y, s.X, s.Y)
+		if dist <= detRadius+s.Radius && env.detects(dr, s.X, s.Y, dist, detRadius+s.Radius, d.rng) {
+			// Le drone détecte le survivant (selon son capteur et le terrain)
+			confirmed := confirmsSurvivor(cfg, dr, s)
+			if !s.Detected {
+				s.Detected = true
+				s.DetectedAt = env.Time + cfg.TimeStep
+				s.DetectedBy = dr.ID
+				if !confirmed {
+					// on fait venir un autre drone pour confirmer
+					env.logEvent(Event{Type: EventSurvivorDetected, Drone: dr.ID, Survivor: ref(s.ID)})
+					d.requestHelp(env, s.X, s.Y, nil, ref(s.ID))
+				}
+			}
+			if !confirmed {
+				continue
+			}
 			s.Saved = true
+			s.FoundAt = env.Time + cfg.TimeStep
+			s.FoundBy = dr.ID
 			dr.FoundID = s.ID
+			env.logEvent(Event{Type: EventSurvivorSaved, Drone: dr.ID, Survivor: ref(s.ID)})
+			d.send(env, Message{Kind: MsgSurvivorFound, To: -1, X: s.X, Y: s.Y, Survivor: ref(s.ID)})
 
 			// Il n'a plus de cible spécifique
 			dr.HasTarget = false
@@ -420,42 +635,6 @@ func (d *DroneAgent) Act(env *Environment) {
 	}
 }
 
-func callNeighborsForHelp(env *Environment, droneIndex, traceIndex int) {
-	cfg := env.Config
-	source := env.Drones[droneIndex]
-	trace := env.Traces[traceIndex]
-
-	type candidate struct {
-		idx  int
-		dist float64
-	}
-
-	var neighbors []candidate
-	for i := range env.Drones {
-		if i == droneIndex {
-			continue
-		}
-		d := &env.Drones[i]
-		if d.Mode != ModeSearching {
-			continue
-		}
-		dist := distance(source.X, source.Y, d.X, d.Y)
-		if dist <= cfg.rayonAide {
-			neighbors = append(neighbors, candidate{idx: i, dist: dist})
-		}
-	}
-	if len(neighbors) > cfg.MaxHelpersPerHit {
-		neighbors = neighbors[:cfg.MaxHelpersPerHit]
-	}
-	for _, n := range neighbors {
-		d := &env.Drones[n.idx]
-		d.Mode = ModeResponding
-		d.TargetX = trace.X
-		d.TargetY = trace.Y
-		d.HasTarget = true
-	}
-}
-
 //
 // ------------------------ Simulation ------------------------
 //
@@ -465,6 +644,24 @@ type Simulation struct {
 	env     Environment
 	agents  []Agent
 	running bool
+	rng     *rand.Rand
+	gen     int        // incrémenté à chaque Reset
+	stream  *streamHub // clients du flux /api/stream
+
+	speed      SimSpeed  // pas par tick de Run (SpeedMax : au plus vite)
+	stepCredit float64   // fractions de pas accumulées (vitesses < 1x)
+	until      *RunUntil // condition d'arrêt de /api/run-until
+
+	recorder *replayRecorder // enregistrement en cours (nil si aucun)
+}
+
+// Les graines passent par le JSON et l'interface : au-delà de 2^53 un nombre
+// JavaScript perd des chiffres et la graine affichée ne rejoue plus le run
+const maxSeed = 1 << 53
+
+// Graine aléatoire dans [1, 2^53)
+func randomSeed() int64 {
+	return time.Now().UnixNano()%(maxSeed-1) + 1
 }
 
 func defaultChargingPoints(cfg SimConfig) []ChargingPoint {
@@ -493,29 +690,31 @@ func addChargingPoint(cfg *SimConfig, x, y float64) {
 
 func defaultConfig() SimConfig {
 	return SimConfig{
-		Width:            1000,
-		Height:           700,
-		NumDrones:        20,
-		NumSurvivors:     5,
-		NumTraces:        8,
-		DroneSpeed:       50,
-		DetectionRadius:  40,
-		rayonAide:        150,
-		MaxHelpersPerHit: 3,
-		TimeStep:         0.1,
-		DroneTypes:       nil,
-		ChargingPoints:   nil,
-		BaseX:            -1,
-		BaseY:            -1,
-
-		tailleIndice:    1.5,
-		tauxExploration: 0.02,
-		dureeEngagement: 8.0,
+		Width:           1000,
+		Height:          700,
+		NumDrones:       20,
+		NumSurvivors:    5,
+		NumTraces:       8,
+		DroneSpeed:      50,
+		DetectionRadius: 40,
+		TimeStep:        0.1,
+		DroneTypes:      nil,
+		ChargingPoints:  nil,
+		BaseX:           -1,
+		BaseY:           -1,
+
+		PolicyParams: PolicyParams{
+			RayonAide:        150,
+			MaxHelpersPerHit: 3,
+			TailleIndice:     1.5,
+			TauxExploration:  0.02,
+			DureeEngagement:  8.0,
+		},
 	}
 }
 
 func NewSimulation(cfg SimConfig) *Simulation {
-	s := &Simulation{}
+	s := &Simulation{stream: newStreamHub(), speed: 1}
 	s.Reset(cfg)
 	return s
 }
@@ -533,8 +732,8 @@ func (s *Simulation) Reset(cfg SimConfig) {
 	if cfg.DetectionRadius <= 0 {
 		cfg.DetectionRadius = 40
 	}
-	if cfg.rayonAide <= 0 {
-		cfg.rayonAide = 150
+	if cfg.RayonAide <= 0 {
+		cfg.RayonAide = 150
 	}
 	if cfg.MaxHelpersPerHit <= 0 {
 		cfg.MaxHelpersPerHit = 3
@@ -542,20 +741,32 @@ func (s *Simulation) Reset(cfg SimConfig) {
 	if cfg.TimeStep <= 0 {
 		cfg.TimeStep = 0.1
 	}
-	if cfg.tailleIndice <= 0 {
-		cfg.tailleIndice = 1.5
+	if cfg.TailleIndice <= 0 {
+		cfg.TailleIndice = 1.5
+	}
+	if cfg.TauxExploration <= 0 {
+		cfg.TauxExploration = 0.02
+	}
+	if cfg.DureeEngagement <= 0 {
+		cfg.DureeEngagement = 8.0
 	}
-	if cfg.tauxExploration <= 0 {
-		cfg.tauxExploration = 0.02
+	if cfg.EnergyReserve <= 0 {
+		cfg.EnergyReserve = defaultEnergyReserve
 	}
-	if cfg.dureeEngagement <= 0 {
-		cfg.dureeEngagement = 8.0
+	if cfg.Knowledge == "" {
+		cfg.Knowledge = KnowledgeOmniscient
 	}
 	if len(cfg.ChargingPoints) == 0 {
 		cfg.ChargingPoints = defaultChargingPoints(cfg)
 	}
+	cfg.Zones = normalizeZones(cfg.Zones)
+	cfg.DroneTypes = priceFleet(cfg.catalog(), cfg.DroneTypes)
 
-	rand.Seed(time.Now().UnixNano())
+	// graine : si non fournie, on en tire une et on la garde pour pouvoir rejouer
+	if cfg.Seed == 0 {
+		cfg.Seed = randomSeed()
+	}
+	rng := rand.New(rand.NewSource(cfg.Seed))
 
 	// pos de base : si pas définie, on centre
 	if cfg.BaseX <= 0 && cfg.BaseY <= 0 {
@@ -592,7 +803,7 @@ func (s *Simulation) Reset(cfg SimConfig) {
 			}
 
 			for i := 0; i < dt.Count; i++ {
-				angle := rand.Float64() * 2 * math.Pi
+				angle := rng.Float64() * 2 * math.Pi
 				drone := Drone{
 					ID:                len(drones),
 					X:                 centerX,
@@ -604,15 +815,21 @@ func (s *Simulation) Reset(cfg SimConfig) {
 					TargetY:           0,
 					HasTarget:         false,
 					FoundID:           -1,
+					Station:           -1,
 					RespondTimer:      0,
 					Speed:             speed,
 					Weight:            dt.Weight,
 					Autonomy:          autonomy,
 					RemainingAutonomy: autonomy,
 					DetectionRadius:   detR,
+					Type:              dt.Name,
+					Sensor:            dt.Sensor,
+					Airframe:          newAirframe(dt),
+					ModeTime:          map[DroneMode]float64{},
 				}
+				drone.initBattery(dt.BatteryWh)
 				drones = append(drones, drone)
-				agents = append(agents, NewDroneAgent(drone.ID, &cfg))
+				agents = append(agents, NewDroneAgent(drone.ID, &cfg, rng))
 			}
 		}
 	} else {
@@ -626,7 +843,7 @@ func (s *Simulation) Reset(cfg SimConfig) {
 		}
 
 		for i := range drones {
-			angle := rand.Float64() * 2 * math.Pi
+			angle := rng.Float64() * 2 * math.Pi
 			speed := cfg.DroneSpeed
 			autonomy := 20.0 // default time-based autonomy in seconds
 
@@ -641,26 +858,36 @@ func (s *Simulation) Reset(cfg SimConfig) {
 				TargetY:           0,
 				HasTarget:         false,
 				FoundID:           -1,
+				Station:           -1,
 				RespondTimer:      0,
 				Speed:             speed,
 				Weight:            1.0,
 				Autonomy:          autonomy,
 				RemainingAutonomy: autonomy,
 				DetectionRadius:   detR,
+				Type:              "default",
+				Airframe:          newAirframe(DroneType{Weight: 1.0}),
+				ModeTime:          map[DroneMode]float64{},
 			}
-			agents[i] = NewDroneAgent(i, &cfg)
+			drones[i].initBattery(0)
+			agents[i] = NewDroneAgent(i, &cfg, rng)
 		}
 	}
 
-	// Survivants
+	// Survivants (jamais dans un obstacle ou une zone interdite)
 	survivors := make([]Survivor, cfg.NumSurvivors)
 	for i := range survivors {
+		x, y, _ := sampleOutsideZones(cfg.Zones, 1000, func() (float64, float64) {
+			return rng.Float64() * cfg.Width, rng.Float64() * cfg.Height
+		})
 		survivors[i] = Survivor{
-			ID:     i,
-			X:      rand.Float64() * cfg.Width,
-			Y:      rand.Float64() * cfg.Height,
-			Saved:  false,
-			Radius: 6, // plus petit
+			ID:         i,
+			X:          x,
+			Y:          y,
+			Saved:      false,
+			Radius:     6, // plus petit
+			FoundBy:    -1,
+			DetectedBy: -1,
 		}
 	}
 
@@ -681,7 +908,7 @@ func (s *Simulation) Reset(cfg SimConfig) {
 			sv := &survivors[i]
 
 			// Rayon de la trace : param entraînable
-			traceRadius := traceBaseRadius * cfg.tailleIndice
+			traceRadius := traceBaseRadius * cfg.TailleIndice
 
 			// On veut que le survivant soit À L’INTÉRIEUR du cercle
 			margin := sv.Radius + 3
@@ -690,24 +917,31 @@ func (s *Simulation) Reset(cfg SimConfig) {
 				rMax = 0
 			}
 
-			rho := rand.Float64() * rMax
-			theta := rand.Float64() * 2 * math.Pi
+			x, y, ok := sampleOutsideZones(cfg.Zones, 100, func() (float64, float64) {
+				rho := rng.Float64() * rMax
+				theta := rng.Float64() * 2 * math.Pi
 
-			x := sv.X + rho*math.Cos(theta)
-			y := sv.Y + rho*math.Sin(theta)
+				x := sv.X + rho*math.Cos(theta)
+				y := sv.Y + rho*math.Sin(theta)
 
-			// clamp dans la map
-			if x < 0 {
-				x = 0
-			}
-			if x > cfg.Width {
-				x = cfg.Width
-			}
-			if y < 0 {
-				y = 0
-			}
-			if y > cfg.Height {
-				y = cfg.Height
+				// clamp dans la map
+				if x < 0 {
+					x = 0
+				}
+				if x > cfg.Width {
+					x = cfg.Width
+				}
+				if y < 0 {
+					y = 0
+				}
+				if y > cfg.Height {
+					y = cfg.Height
+				}
+				return x, y
+			})
+			if !ok {
+				// survivant collé à une zone : la trace est centrée sur lui
+				x, y = sv.X, sv.Y
 			}
 
 			traces[i] = Trace{
@@ -724,11 +958,14 @@ func (s *Simulation) Reset(cfg SimConfig) {
 		// fallback si vraiment aucun survivant : traces libres sans lien
 		traces = make([]Trace, cfg.NumTraces)
 		for i := range traces {
+			x, y, _ := sampleOutsideZones(cfg.Zones, 1000, func() (float64, float64) {
+				return rng.Float64() * cfg.Width, rng.Float64() * cfg.Height
+			})
 			traces[i] = Trace{
 				ID:         i,
-				X:          rand.Float64() * cfg.Width,
-				Y:          rand.Float64() * cfg.Height,
-				Radius:     traceBaseRadius * cfg.tailleIndice,
+				X:          x,
+				Y:          y,
+				Radius:     traceBaseRadius * cfg.TailleIndice,
 				Consumed:   false,
 				SurvivorID: -1,
 				Activated:  false,
@@ -738,6 +975,8 @@ func (s *Simulation) Reset(cfg SimConfig) {
 
 	s.mu.Lock()
 	defer s.mu.Unlock()
+	// un replay couvre un seul monde : le reset clôt l'enregistrement
+	s.stopRecordingLocked()
 	gridW := int(cfg.Width / 20)
 	gridH := int(cfg.Height / 20)
 
@@ -751,20 +990,35 @@ func (s *Simulation) Reset(cfg SimConfig) {
 		Drones:         drones,
 		Survivors:      survivors,
 		Traces:         traces,
-		ChargingPoints: cfg.ChargingPoints,
+		Alerts:         []Trace{},
+		ChargingPoints: newStations(cfg.ChargingPoints),
+		Zones:          cfg.Zones,
+		planner:        newPlanner(cfg),
+		Terrain:        newTerrain(cfg),
+		wind:           newWind(cfg.Wind, rng),
 		Time:           0,
 		Finished:       false,
-		Stats:          SimStats{},
+		Stats:          SimStats{Seed: cfg.Seed},
 		Heatmap:        heat,
+		BaseBelief:     newBeliefMap(&cfg),
 	}
+	s.env.updateWind()
 	s.agents = agents
 	s.running = true
+	s.rng = rng
+	s.gen++
+	s.stepCredit = 0
+	s.until = nil
 }
 
 func (s *Simulation) step() {
 	s.mu.Lock()
 	defer s.mu.Unlock()
+	s.stepLocked()
+}
 
+// Un pas de simulation, verrou déjà pris
+func (s *Simulation) stepLocked() {
 	if s.env.Finished {
 		return
 	}
@@ -776,7 +1030,11 @@ func (s *Simulation) step() {
 	}
 
 	s.env.Time += s.env.Config.TimeStep
-	for _, d := range s.env.Drones {
+	s.env.updateWind()
+	for i := range s.env.Drones {
+		d := &s.env.Drones[i]
+		d.ModeTime[d.Mode] += s.env.Config.TimeStep
+
 		ix := int(d.X / 20)
 		iy := int(d.Y / 20)
 
@@ -787,11 +1045,8 @@ func (s *Simulation) step() {
 
 	// Vérifier si tous les survivants sont sauvés
 	allSaved := true
-	savedCount := 0
 	for i := range s.env.Survivors {
-		if s.env.Survivors[i].Saved {
-			savedCount++
-		} else {
+		if !s.env.Survivors[i].Saved {
 			allSaved = false
 		}
 	}
@@ -812,25 +1067,89 @@ func (s *Simulation) step() {
 
 	if allSaved {
 		// calcul des stats finales
-		tracesConsumed := 0
-		for i := range s.env.Traces {
-			if s.env.Traces[i].Consumed {
-				tracesConsumed++
+		s.env.Finished = true
+		s.env.Stats = s.env.computeStats()
+		s.running = false
+	}
+	s.recordLocked()
+}
+
+// Stats de la simulation à l'instant courant (terminée ou non)
+func (e *Environment) computeStats() SimStats {
+	stats := SimStats{
+		TotalTime:      e.Time,
+		TotalSurvivors: len(e.Survivors),
+		Drones:         len(e.Drones),
+		Traces:         len(e.Traces),
+		Finished:       e.Finished,
+		Seed:           e.Config.Seed,
+		FleetCost:      fleetCost(e.Config.DroneTypes),
+	}
+	for _, tr := range e.Traces {
+		if tr.Consumed {
+			stats.TracesConsumed++
+		}
+	}
+	confirmed := 0
+	for _, sv := range e.Survivors {
+		if !sv.Saved {
+			if sv.Detected {
+				stats.PendingConfirmations++
 			}
+			continue
 		}
-		stats := SimStats{
-			TotalTime:      s.env.Time,
-			TotalSurvivors: len(s.env.Survivors),
-			SavedSurvivors: savedCount,
-			Drones:         len(s.env.Drones),
-			Traces:         len(s.env.Traces),
-			TracesConsumed: tracesConsumed,
-			Finished:       true,
+		if sv.FoundAt > sv.DetectedAt {
+			stats.MeanConfirmationDelay += sv.FoundAt - sv.DetectedAt
+			confirmed++
+		}
+		stats.SavedSurvivors++
+		if stats.FirstFindTime == 0 || sv.FoundAt < stats.FirstFindTime {
+			stats.FirstFindTime = sv.FoundAt
+		}
+		stats.LastFindTime = math.Max(stats.LastFindTime, sv.FoundAt)
+	}
+	if confirmed > 0 {
+		stats.MeanConfirmationDelay /= float64(confirmed)
+	}
+	for _, d := range e.Drones {
+		stats.EnergyConsumed += d.EnergyUsed
+		stats.Recharges += d.Recharges
+		stats.FalsePositives += d.FalsePositives
+		stats.MissedDetections += d.MissedDetections
+		stats.QueueTime += d.ModeTime[ModeWaiting]
+		stats.ChargingTime += d.ModeTime[ModeCharging]
+		stats.MessagesSent += d.MessagesSent
+		stats.MessagesReceived += d.MessagesReceived
+		stats.MessagesLost += d.MessagesLost
+	}
+	if stats.Recharges > 0 {
+		stats.MeanQueueWait = stats.QueueTime / float64(stats.Recharges)
+	}
+
+	cells, unvisited := 0, 0
+	for _, col := range e.Heatmap {
+		for _, v := range col {
+			cells++
+			if v == 0 {
+				unvisited++
+			}
+		}
+	}
+	if cells > 0 {
+		stats.UnvisitedFraction = float64(unvisited) / float64(cells)
+	}
+	return stats
+}
+
+// Coût total d'une flotte
+func fleetCost(types []DroneType) float64 {
+	var cost float64
+	for _, dt := range types {
+		if dt.Count > 0 {
+			cost += float64(dt.Count) * dt.Price
 		}
-		s.env.Stats = stats
-		s.env.Finished = true
-		s.running = false
 	}
+	return cost
 }
 
 func (s *Simulation) Run(ctx context.Context) {
@@ -842,12 +1161,8 @@ func (s *Simulation) Run(ctx context.Context) {
 		case <-ctx.Done():
 			return
 		case <-ticker.C:
-			s.mu.RLock()
-			running := s.running
-			s.mu.RUnlock()
-			if running {
-				s.step()
-			}
+			s.tick()
+			s.publish()
 		}
 	}
 }
@@ -855,7 +1170,34 @@ func (s *Simulation) Run(ctx context.Context) {
 func (s *Simulation) Snapshot() Environment {
 	s.mu.RLock()
 	defer s.mu.RUnlock()
-	return s.env
+	return s.env.clone()
+}
+
+// Copie profonde : la simulation peut continuer pendant qu'on sérialise la copie
+func (e Environment) clone() Environment {
+	c := e
+	c.Drones = make([]Drone, len(e.Drones))
+	for i, d := range e.Drones {
+		d.ModeTime = make(map[DroneMode]float64, len(e.Drones[i].ModeTime))
+		for m, t := range e.Drones[i].ModeTime {
+			d.ModeTime[m] = t
+		}
+		c.Drones[i] = d
+	}
+	c.ChargingPoints = cloneStations(e.ChargingPoints)
+	c.Survivors = append([]Survivor(nil), e.Survivors...)
+	c.Traces = append([]Trace(nil), e.Traces...)
+	c.Alerts = append([]Trace(nil), e.Alerts...)
+	c.Radio = append([]Transmission(nil), e.Radio...)
+	c.BaseBelief = e.BaseBelief.clone()
+	c.Heatmap = make([][]float64, len(e.Heatmap))
+	for i := range e.Heatmap {
+		c.Heatmap[i] = append([]float64(nil), e.Heatmap[i]...)
+	}
+	// le journal ne fait que grandir : on partage les événements déjà écrits,
+	// la capacité bornée empêche tout ajout de la copie d'écraser l'original
+	c.Events = e.Events[:len(e.Events):len(e.Events)]
+	return c
 }
 
 func (s *Simulation) SetRunning(r bool) {
@@ -872,6 +1214,9 @@ func (s *Simulation) ToggleRunning() bool {
 		return s.running
 	}
 	s.running = !s.running
+	if !s.running {
+		s.until = nil
+	}
 	return s.running
 }
 
@@ -879,42 +1224,62 @@ func (s *Simulation) ToggleRunning() bool {
 // ------------------------ HTTP ------------------------
 //
 
-var (
-	sim        *Simulation
-	baseConfig SimConfig
-)
-
 func writeJSON(w http.ResponseWriter, v any) {
 	w.Header().Set("Content-Type", "application/json")
-	enc := json.NewEncoder(w)
-	enc.SetIndent("", "  ")
-	if err := enc.Encode(v); err != nil {
+	if err := json.NewEncoder(w).Encode(v); err != nil {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 	}
 }
 
-func handleGetState(w http.ResponseWriter, r *http.Request) {
-	env := sim.Snapshot()
+// Erreur structurée renvoyée par l'API : {"error": {"code", "message", "details"}}
+type APIError struct {
+	Code    string `json:"code"`
+	Message string `json:"message"`
+	Details any    `json:"details,omitempty"`
+}
+
+func writeAPIError(w http.ResponseWriter, status int, e APIError) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	enc := json.NewEncoder(w)
+	enc.SetEscapeHTML(false)
+	enc.Encode(map[string]APIError{"error": e})
+}
+
+func handleGetState(w http.ResponseWriter, r *http.Request, sess *Session) {
+	env := sess.sim.Snapshot()
 	writeJSON(w, env)
 }
 
-func handleReset(w http.ResponseWriter, r *http.Request) {
+func handleReset(w http.ResponseWriter, r *http.Request, sess *Session) {
+	// corps vide : on repart de la config de base telle quelle
 	var reqCfg SimConfig
-	if r.Body != nil {
-		defer r.Body.Close()
-		if err := json.NewDecoder(r.Body).Decode(&reqCfg); err != nil {
-			reqCfg = defaultConfig()
-		}
-	} else {
-		reqCfg = defaultConfig()
+	if !decodeBody(w, r, &reqCfg) {
+		return
+	}
+	cfg, apiErr := requestConfig(sess.base, reqCfg)
+	if apiErr != nil {
+		writeAPIError(w, http.StatusBadRequest, *apiErr)
+		return
 	}
 
+	sess.sim.Reset(cfg)
+	env := sess.sim.Snapshot()
+	writeJSON(w, env)
+}
+
+// Config de base surchargée par les paramètres envoyés par un client
+func requestConfig(base, reqCfg SimConfig) (SimConfig, *APIError) {
 	// On repart de la config de base
-	cfg := baseConfig
+	cfg := base
 
 	if len(reqCfg.DroneTypes) > 0 {
-		// Si le front envoie des types, on les utilise
-		cfg.DroneTypes = reqCfg.DroneTypes
+		// Si le front envoie des types, ils doivent exister au catalogue et tenir dans le budget
+		fleet, apiErr := validateFleet(cfg, reqCfg.DroneTypes)
+		if apiErr != nil {
+			return cfg, apiErr
+		}
+		cfg.DroneTypes = fleet
 		// On remet NumDrones à 0 pour forcer la logique par types dans Simulation.Reset
 		cfg.NumDrones = 0
 	} else if len(cfg.DroneTypes) == 0 && reqCfg.NumDrones > 0 {
@@ -935,101 +1300,198 @@ func handleReset(w http.ResponseWriter, r *http.Request) {
 	if reqCfg.DetectionRadius > 0 {
 		cfg.DetectionRadius = reqCfg.DetectionRadius
 	}
+	if len(reqCfg.Zones) > 0 {
+		cfg.Zones = reqCfg.Zones
+	}
+	if !reqCfg.Terrain.empty() {
+		// les rasters ne sont lus qu'au chargement de la config du serveur
+		reqCfg.Terrain.Image = ""
+		if err := validateTerrain(reqCfg.Terrain); err != nil {
+			return cfg, &APIError{Code: "invalid_terrain", Message: err.Error()}
+		}
+		cfg.Terrain = reqCfg.Terrain
+	}
+	if reqCfg.Knowledge != "" {
+		if err := validateKnowledge(reqCfg.Knowledge); err != nil {
+			return cfg, &APIError{Code: "invalid_knowledge", Message: err.Error(), Details: map[string]any{"knowledge": reqCfg.Knowledge}}
+		}
+		cfg.Knowledge = reqCfg.Knowledge
+	}
+	if reqCfg.Wind.Kind != "" {
+		// idem pour les fichiers de champ de vent : un champ doit venir en ligne
+		reqCfg.Wind.File = ""
+		if err := validateWind(reqCfg.Wind); err != nil {
+			return cfg, &APIError{Code: "invalid_wind", Message: err.Error(), Details: map[string]any{"kind": reqCfg.Wind.Kind}}
+		}
+		cfg.Wind = reqCfg.Wind
+	}
+	// paramètres de politique envoyés par le client
+	cfg.PolicyParams.Merge(reqCfg.PolicyParams)
+
+	// graine explicite : même graine + même config => même déroulé
+	if reqCfg.Seed != 0 {
+		cfg.Seed = reqCfg.Seed
+	}
 	// On ne touche NumDrones que si on n'utilise pas de types de drones
 	if len(cfg.DroneTypes) == 0 && reqCfg.NumDrones > 0 {
 		cfg.NumDrones = reqCfg.NumDrones
 	}
+	return cfg, nil
+}
 
-	sim.Reset(cfg)
-	env := sim.Snapshot()
-	writeJSON(w, env)
+func handleToggle(w http.ResponseWriter, r *http.Request, sess *Session) {
+	sess.sim.ToggleRunning()
+	writeJSON(w, sess.sim.controlState())
 }
 
-func handleToggle(w http.ResponseWriter, r *http.Request) {
-	running := sim.ToggleRunning()
-	writeJSON(w, map[string]any{
-		"running": running,
-	})
+// Fichier d'entrée. Required quand il a été donné explicitement : il doit
+// alors exister et être valide, sinon on retombe sur les défauts.
+type inputFile struct {
+	Path     string
+	Required bool
+}
+
+func loadConfig(in inputFile) (SimConfig, error) {
+	cfg, err := readConfig(in.Path)
+	if err == nil || in.Required {
+		return cfg, err
+	}
+	if errors.Is(err, os.ErrNotExist) {
+		log.Printf("No config file %s found, using defaults.", in.Path)
+	} else {
+		log.Printf("Config file %s invalid, using defaults: %v", in.Path, err)
+	}
+	return defaultConfig(), nil
 }
 
-func loadConfig(path string) SimConfig {
+func readConfig(path string) (SimConfig, error) {
 	file, err := os.Open(path)
 	if err != nil {
-		log.Println("No config file found, using defaults.")
-		return defaultConfig()
+		return SimConfig{}, err
 	}
 	defer file.Close()
 
 	var cfg SimConfig
 	if err := json.NewDecoder(file).Decode(&cfg); err != nil {
-		log.Println("Config file invalid, using defaults:", err)
-		return defaultConfig()
+		return SimConfig{}, fmt.Errorf("config %s invalide : %w", path, err)
+	}
+	if err := cfg.loadTerrainImage(filepath.Dir(path)); err != nil {
+		return SimConfig{}, fmt.Errorf("image de terrain de %s : %w", path, err)
+	}
+	if err := validateTerrain(cfg.Terrain); err != nil {
+		return SimConfig{}, fmt.Errorf("config %s : %w", path, err)
+	}
+	if err := cfg.loadWindField(filepath.Dir(path)); err != nil {
+		return SimConfig{}, fmt.Errorf("champ de vent de %s : %w", path, err)
+	}
+	if err := validateWind(cfg.Wind); err != nil {
+		return SimConfig{}, fmt.Errorf("config %s : %w", path, err)
 	}
-	return cfg
+	if err := validateDroneTypes(cfg.catalog(), cfg.DroneTypes); err != nil {
+		return SimConfig{}, fmt.Errorf("config %s : %w", path, err)
+	}
+	if err := validateScoringProfile(cfg.Scoring.Profile); err != nil {
+		return SimConfig{}, fmt.Errorf("config %s : %w", path, err)
+	}
+	return cfg, nil
 }
 
-// Config de policy apprise par le batch
+// Config de policy apprise par le batch (format de best_policy.json)
 type LearnedPolicyConfig struct {
-	rayonAide        float64 `json:"rayonAide"`
-	MaxHelpersPerHit int     `json:"maxHelpersPerHit"`
-	tailleIndice     float64 `json:"tailleIndice"`
-	tauxExploration  float64 `json:"tauxExploration"`
-	dureeEngagement  float64 `json:"dureeEngagement"`
+	PolicyParams
+	Fleet []DroneType `json:"fleet,omitempty"` // flotte optimisée avec la policy, si recherchée
 }
 
-func loadBestPolicy(path string) (LearnedPolicyConfig, bool) {
-	file, err := os.Open(path)
+// Applique une policy apprise (et sa flotte éventuelle) à la config
+func (c *SimConfig) applyLearned(pol LearnedPolicyConfig) {
+	c.PolicyParams.Merge(pol.PolicyParams)
+	if len(pol.Fleet) > 0 {
+		c.DroneTypes = pol.Fleet
+		c.NumDrones = 0
+	}
+}
+
+// Policy apprise de in (ok faux si le fichier est absent et facultatif)
+func loadBestPolicy(in inputFile) (pol LearnedPolicyConfig, ok bool, err error) {
+	file, err := os.Open(in.Path)
 	if err != nil {
-		return LearnedPolicyConfig{}, false
+		if in.Required || !errors.Is(err, os.ErrNotExist) {
+			return LearnedPolicyConfig{}, false, err
+		}
+		return LearnedPolicyConfig{}, false, nil
 	}
 	defer file.Close()
 
-	var pol LearnedPolicyConfig
 	if err := json.NewDecoder(file).Decode(&pol); err != nil {
-		log.Println("best_policy.json invalide, ignoré:", err)
-		return LearnedPolicyConfig{}, false
+		if in.Required {
+			return LearnedPolicyConfig{}, false, fmt.Errorf("policy %s invalide : %w", in.Path, err)
+		}
+		log.Printf("%s invalide, ignoré: %v", in.Path, err)
+		return LearnedPolicyConfig{}, false, nil
 	}
-	return pol, true
+	return pol, true, nil
 }
 
 func main() {
-	// Mode batch offline
-	print("os.Getenv(TRAIN_BATCH) =", os.Getenv("TRAIN_BATCH"))
-	if os.Getenv("TRAIN_BATCH") == "1" {
-		RunBatchTraining()
-		return
-	}
+	os.Exit(runCLI(os.Args[1:]))
+}
 
-	// Mode serveur normal
-	cfg := loadConfig("config.json")
+// Options du serveur web
+type ServeOptions struct {
+	Config      inputFile
+	Policy      inputFile
+	FrontPath   string // front de Pareto : remplace Policy si renseigné
+	Point       string // point du front (index ou sélecteur nommé)
+	Port        string
+	WebDir      string
+	Seed        int64         // graine des simulations (0 = aléatoire)
+	IdleTimeout time.Duration // inactivité avant suppression d'une session
+	ReplayDir   string        // dossier des replays enregistrés
+}
 
-	// surcharge avec la policy apprise si présente
-	if _, ok := loadBestPolicy("best_policy.json"); ok {
-		cfg.rayonAide = 356.8
-		cfg.MaxHelpersPerHit = 2
-		cfg.tailleIndice = 2.97
-		cfg.tauxExploration = 0.012
-		cfg.dureeEngagement = 8.1
-		log.Printf("Using learned policy: rayonAide=%.1f, maxHelpers=%d, traceFactor=%.2f, exploreRate=%.3f, timeout=%.1fs",
-			cfg.rayonAide, cfg.MaxHelpersPerHit, cfg.tailleIndice, cfg.tauxExploration, cfg.dureeEngagement)
+// Lance le serveur web de simulation
+func runServer(opts ServeOptions) error {
+	cfg, err := loadScenarioPoint(opts.Config, opts.Policy, opts.FrontPath, opts.Point)
+	if err != nil {
+		return err
+	}
+	if opts.Seed != 0 {
+		cfg.Seed = opts.Seed
 	}
 
-	// on garde cette config comme "base" avec entraînement appliqué
-	baseConfig = cfg
-
-	sim = NewSimulation(cfg)
+	// on garde cette config comme "base" avec entraînement appliqué :
+	// chaque session part de cette config
+	idle := opts.IdleTimeout
+	if idle <= 0 {
+		idle = defaultIdleTimeout
+	}
+	sessions := NewSessionManager(cfg, idle)
+	replays := ReplayStore{dir: opts.ReplayDir}
 
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
-	go sim.Run(ctx)
+	go sessions.collect(ctx)
 
 	mux := http.NewServeMux()
 
-	mux.HandleFunc("/api/state", handleGetState)
-	mux.HandleFunc("/api/reset", handleReset)
-	mux.HandleFunc("/api/toggle", handleToggle)
-
-	webDir := "web"
+	mux.HandleFunc("GET /api/catalog", sessions.handleCatalog)
+	mux.HandleFunc("GET /api/sims", sessions.handleList)
+	mux.HandleFunc("POST /api/sims", sessions.handleCreate)
+	mux.HandleFunc("DELETE /api/sims/{id}", sessions.handleDelete)
+	mux.HandleFunc("GET /api/sims/{id}/state", sessions.route(handleGetState))
+	mux.HandleFunc("POST /api/sims/{id}/reset", sessions.route(handleReset))
+	mux.HandleFunc("POST /api/sims/{id}/toggle", sessions.route(handleToggle))
+	mux.HandleFunc("GET /api/sims/{id}/stream", sessions.route(handleStream))
+	mux.HandleFunc("/api/sims/{id}/speed", sessions.route(handleSpeed))
+	mux.HandleFunc("POST /api/sims/{id}/step", sessions.route(handleStep))
+	mux.HandleFunc("POST /api/sims/{id}/run-until", sessions.route(handleRunUntil))
+	mux.HandleFunc("GET /api/sims/{id}/events", sessions.route(handleEvents))
+	mux.HandleFunc("POST /api/sims/{id}/record", sessions.route(replays.handleRecord))
+	mux.HandleFunc("POST /api/sims/{id}/record/stop", sessions.route(replays.handleStopRecord))
+	mux.HandleFunc("GET /api/replays", replays.handleList)
+	mux.HandleFunc("GET /api/replays/{name}", replays.handleGet)
+
+	webDir := opts.WebDir
 	fs := http.FileServer(http.Dir(webDir))
 
 	mux.Handle("/", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
@@ -1040,31 +1502,52 @@ func main() {
 		fs.ServeHTTP(w, r)
 	}))
 
-	port := os.Getenv("PORT")
-	if port == "" {
-		port = "8080"
+	log.Printf("Server listening on http://localhost:%s", opts.Port)
+	return http.ListenAndServe(":"+opts.Port, mux)
+}
+
+// Config de base surchargée par la policy apprise si présente (Path vide : aucune)
+func loadScenario(config, policy inputFile) (SimConfig, error) {
+	cfg, err := loadConfig(config)
+	if err != nil || policy.Path == "" {
+		return cfg, err
 	}
-	log.Printf("Server listening on http://localhost:%s", port)
-	if err := http.ListenAndServe(":"+port, mux); err != nil {
-		log.Fatal(err)
+	pol, ok, err := loadBestPolicy(policy)
+	if err != nil {
+		return SimConfig{}, err
+	}
+	if ok {
+		cfg.applyLearned(pol)
+		log.Printf("Using learned policy %s: rayonAide=%.1f, maxHelpers=%d, traceFactor=%.2f, exploreRate=%.3f, timeout=%.1fs",
+			policy.Path, cfg.RayonAide, cfg.MaxHelpersPerHit, cfg.TailleIndice, cfg.TauxExploration, cfg.DureeEngagement)
+		if len(pol.Fleet) > 0 {
+			log.Printf("Using learned fleet: %s", describeFleet(pol.Fleet))
+		}
 	}
+	return cfg, nil
 }
 
-func findNearestChargingPoint(droneX, droneY float64, points []ChargingPoint) (float64, float64) {
-	if len(points) == 0 {
-		return 0, 0 // fallback, but shouldn't happen
-	}
-	minDist := math.Inf(1)
-	var nearestX, nearestY float64
-	for _, p := range points {
-		dist := distance(droneX, droneY, p.X, p.Y)
-		if dist < minDist {
-			minDist = dist
-			nearestX = p.X
-			nearestY = p.Y
-		}
+// Comme loadScenario, mais la policy peut venir d'un point du front de Pareto
+func loadScenarioPoint(config, policy inputFile, frontPath, point string) (SimConfig, error) {
+	if frontPath == "" {
+		return loadScenario(config, policy)
+	}
+	front, err := loadParetoFront(frontPath)
+	if err != nil {
+		return SimConfig{}, err
+	}
+	pt, err := front.pick(point)
+	if err != nil {
+		return SimConfig{}, err
+	}
+	cfg, err := loadConfig(config)
+	if err != nil {
+		return SimConfig{}, err
 	}
-	return nearestX, nearestY
+	cfg.applyLearned(pt.Policy)
+	log.Printf("Using Pareto point (candidate %d): rescue=%.1f%%, time=%.1fs, energy=%.1f, cost=%.0f",
+		pt.Candidate, 100*pt.Objectives.RescueRate, pt.Objectives.TotalTime, pt.Objectives.Energy, pt.Objectives.FleetCost)
+	return cfg, nil
 }
 
 func distance(x1, y1, x2, y2 float64) float64 {
@@ -0,0 +1,73 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+// Scénario court et complet (zones, terrain, vent, radio) pour les tests
+func testConfig(seed int64) SimConfig {
+	cfg := defaultConfig()
+	cfg.Seed = seed
+	cfg.NumSurvivors = 4
+	cfg.Zones = []Zone{{ID: 0, Kind: ZoneObstacle, Polygon: []Point{{300, 200}, {400, 200}, {400, 300}, {300, 300}}}}
+	cfg.Wind = WindConfig{Kind: WindGusty, Speed: 5, Direction: 30}
+	cfg.Comms = CommsConfig{Latency: 0.2, LossRate: 0.1, Relay: true}
+	return cfg
+}
+
+func TestSameSeedSameRun(t *testing.T) {
+	a := runSimulation(testConfig(42), 2000)
+	b := runSimulation(testConfig(42), 2000)
+	if !reflect.DeepEqual(a.Stats, b.Stats) {
+		t.Fatalf("stats differ:\n%+v\n%+v", a.Stats, b.Stats)
+	}
+	for i := range a.Drones {
+		da, db := a.Drones[i], b.Drones[i]
+		if da.X != db.X || da.Y != db.Y || da.DistanceFlown != db.DistanceFlown || da.EnergyUsed != db.EnergyUsed {
+			t.Fatalf("drone %d trajectory differs: (%v,%v) vs (%v,%v)", i, da.X, da.Y, db.X, db.Y)
+		}
+	}
+	if !reflect.DeepEqual(a.Events, b.Events) {
+		t.Fatal("event logs differ")
+	}
+}
+
+func TestRandomSeedFitsJavaScriptNumbers(t *testing.T) {
+	for i := 0; i < 100; i++ {
+		if s := randomSeed(); s < 1 || s >= maxSeed {
+			t.Fatalf("seed %d outside [1, 2^53)", s)
+		}
+	}
+	s := NewSimulation(SimConfig{})
+	if seed := s.Snapshot().Config.Seed; seed < 1 || seed >= maxSeed {
+		t.Fatalf("reset seed %d outside [1, 2^53)", seed)
+	}
+}
+
+func TestExplicitInputFiles(t *testing.T) {
+	dir := t.TempDir()
+	missing := filepath.Join(dir, "missing.json")
+	invalid := filepath.Join(dir, "invalid.json")
+	if err := os.WriteFile(invalid, []byte("{"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	// chemins par défaut : on retombe sur les défauts
+	if _, err := loadScenario(inputFile{Path: missing}, inputFile{Path: invalid}); err != nil {
+		t.Fatalf("optional files: %v", err)
+	}
+	// chemins explicites : erreur
+	for _, tc := range []struct{ config, policy inputFile }{
+		{inputFile{Path: missing, Required: true}, inputFile{}},
+		{inputFile{Path: invalid, Required: true}, inputFile{}},
+		{inputFile{Path: missing}, inputFile{Path: missing, Required: true}},
+		{inputFile{Path: missing}, inputFile{Path: invalid, Required: true}},
+	} {
+		if _, err := loadScenario(tc.config, tc.policy); err == nil {
+			t.Errorf("loadScenario(%+v, %+v) accepted a missing or invalid explicit file", tc.config, tc.policy)
+		}
+	}
+}
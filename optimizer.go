@@ -0,0 +1,613 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+	"sort"
+)
+
+// Une combinaison entraînable : la policy, et la flotte si elle est optimisée
+type Candidate struct {
+	Policy PolicyParams
+	Fleet  []int // nb de drones par type du catalogue (nil : flotte de la config)
+}
+
+// Optimiseur de politique : propose des combinaisons, puis apprend de leurs scores.
+// Les scores sont à maximiser.
+type Optimizer interface {
+	Name() string
+	// Propose au plus n combinaisons à évaluer (vide : recherche terminée)
+	Propose(n int) []Candidate
+	// Scores des combinaisons évaluées, dans le même ordre
+	Observe(cands []Candidate, scores []float64)
+}
+
+// Noms acceptés par newOptimizer
+var optimizerNames = []string{"random", "grid", "genetic", "cmaes"}
+
+func newOptimizer(name string, space searchSpace, budget int, rng *rand.Rand) (Optimizer, error) {
+	switch name {
+	case "", "random":
+		return &randomSearch{space: space, rng: rng}, nil
+	case "grid":
+		return newGridSearch(space, budget), nil
+	case "genetic":
+		return newGeneticSearch(space, rng), nil
+	case "cmaes":
+		return newCMAES(space, rng), nil
+	}
+	return nil, fmt.Errorf("optimiseur inconnu %q (choix : %v)", name, optimizerNames)
+}
+
+//
+// ------------------------ Espace de recherche ------------------------
+//
+
+// Une dimension de l'espace de recherche, normalisée dans [0, 1]
+type paramDim struct {
+	name    string
+	min     float64
+	max     float64
+	integer bool
+}
+
+// Espace de recherche : les 5 paramètres de policy, puis (optionnel)
+// le nombre de drones de chaque type du catalogue, sous contrainte de budget
+type searchSpace struct {
+	dims    []paramDim
+	catalog []DroneType // types de drones de la flotte recherchée (nil : flotte fixe)
+	budget  float64
+}
+
+const numPolicyDims = 5
+
+// Plages de recherche
+func defaultSearchSpace() searchSpace {
+	return searchSpace{dims: []paramDim{
+		{name: "rayonAide", min: 100, max: 400},
+		{name: "maxHelpersPerHit", min: 1, max: 6, integer: true},
+		{name: "tailleIndice", min: 1.0, max: 3.0},
+		{name: "tauxExploration", min: 0.01, max: 0.20},
+		{name: "dureeEngagement", min: 3.0, max: 12.0},
+	}}
+}
+
+// Ajoute une dimension par type du catalogue (0 à budget/prix drones).
+// Erreur si aucune flotte ne tient dans le budget.
+func fleetSearchSpace(catalog []DroneType, budget float64) (searchSpace, error) {
+	if err := repairFleet(catalog, make([]int, len(catalog)), budget); err != nil {
+		return searchSpace{}, err
+	}
+	sp := defaultSearchSpace()
+	sp.catalog = catalog
+	sp.budget = budget
+	for _, dt := range catalog {
+		maxCount := 20.0 // type gratuit : borne arbitraire
+		if dt.Price > 0 {
+			maxCount = math.Min(50, math.Floor(budget/dt.Price))
+		}
+		sp.dims = append(sp.dims, paramDim{name: "count_" + dt.Name, min: 0, max: maxCount, integer: true})
+	}
+	return sp, nil
+}
+
+func (sp searchSpace) size() int { return len(sp.dims) }
+
+// Vecteur normalisé -> combinaison (bornée, entiers arrondis, flotte sous budget)
+func (sp searchSpace) decode(x []float64) Candidate {
+	v := make([]float64, len(sp.dims))
+	for i, d := range sp.dims {
+		u := clamp01(x[i])
+		v[i] = d.min + u*(d.max-d.min)
+		if d.integer {
+			v[i] = math.Round(v[i])
+		}
+	}
+	c := Candidate{Policy: PolicyParams{
+		RayonAide:        v[0],
+		MaxHelpersPerHit: int(v[1]),
+		TailleIndice:     v[2],
+		TauxExploration:  v[3],
+		DureeEngagement:  v[4],
+	}}
+	if len(sp.catalog) > 0 {
+		c.Fleet = make([]int, len(sp.catalog))
+		for i := range c.Fleet {
+			c.Fleet[i] = int(v[numPolicyDims+i])
+		}
+		if err := repairFleet(sp.catalog, c.Fleet, sp.budget); err != nil {
+			panic(err) // exclu par fleetSearchSpace
+		}
+	}
+	return c
+}
+
+// Combinaison -> policy enregistrable (flotte détaillée depuis le catalogue)
+func (sp searchSpace) learned(c Candidate) LearnedPolicyConfig {
+	pol := LearnedPolicyConfig{PolicyParams: c.Policy}
+	if c.Fleet != nil {
+		pol.Fleet = fleetFromCounts(sp.catalog, c.Fleet)
+	}
+	return pol
+}
+
+// Combinaison -> vecteur normalisé
+func (sp searchSpace) encode(c Candidate) []float64 {
+	p := c.Policy
+	v := []float64{p.RayonAide, float64(p.MaxHelpersPerHit), p.TailleIndice, p.TauxExploration, p.DureeEngagement}
+	for _, n := range c.Fleet {
+		v = append(v, float64(n))
+	}
+	x := make([]float64, len(sp.dims))
+	for i, d := range sp.dims {
+		if d.max > d.min {
+			x[i] = clamp01((v[i] - d.min) / (d.max - d.min))
+		}
+	}
+	return x
+}
+
+func (sp searchSpace) random(rng *rand.Rand) []float64 {
+	x := make([]float64, len(sp.dims))
+	for i := range x {
+		x[i] = rng.Float64()
+	}
+	return x
+}
+
+func clamp01(u float64) float64 {
+	return math.Max(0, math.Min(1, u))
+}
+
+//
+// ------------------------ Recherche aléatoire ------------------------
+//
+
+// Recherche aléatoire pure (comportement historique du batch)
+type randomSearch struct {
+	space searchSpace
+	rng   *rand.Rand
+}
+
+func (o *randomSearch) Name() string { return "random" }
+
+func (o *randomSearch) Propose(n int) []Candidate {
+	out := make([]Candidate, n)
+	for i := range out {
+		out[i] = o.space.decode(o.space.random(o.rng))
+	}
+	return out
+}
+
+func (o *randomSearch) Observe([]Candidate, []float64) {}
+
+//
+// ------------------------ Grille ------------------------
+//
+
+// Grille régulière : les niveaux sont ajoutés dimension par dimension, tour à
+// tour, tant que la grille tient dans le budget
+type gridSearch struct {
+	points [][]float64
+	space  searchSpace
+	next   int
+}
+
+func newGridSearch(space searchSpace, budget int) *gridSearch {
+	levels := gridLevels(space, budget)
+
+	// produit cartésien des niveaux (un seul niveau : milieu de la plage)
+	points := [][]float64{{}}
+	for _, l := range levels {
+		var next [][]float64
+		for _, p := range points {
+			for k := 0; k < l; k++ {
+				u := 0.5
+				if l > 1 {
+					u = float64(k) / float64(l-1)
+				}
+				next = append(next, append(append([]float64(nil), p...), u))
+			}
+		}
+		points = next
+	}
+	return &gridSearch{points: points, space: space}
+}
+
+// Nombre de niveaux par dimension, de produit au plus budget (au moins un
+// point). Une dimension entière n'a pas plus de niveaux que de valeurs.
+func gridLevels(space searchSpace, budget int) []int {
+	levels := make([]int, space.size())
+	for i := range levels {
+		levels[i] = 1
+	}
+	total := 1
+	for grown := true; grown; {
+		grown = false
+		for i, d := range space.dims {
+			l := levels[i]
+			if d.integer && l >= int(d.max-d.min)+1 {
+				continue
+			}
+			if total/l*(l+1) > budget {
+				continue
+			}
+			total = total / l * (l + 1)
+			levels[i]++
+			grown = true
+		}
+	}
+	return levels
+}
+
+func (o *gridSearch) Name() string { return "grid" }
+
+func (o *gridSearch) Propose(n int) []Candidate {
+	var out []Candidate
+	for len(out) < n && o.next < len(o.points) {
+		out = append(out, o.space.decode(o.points[o.next]))
+		o.next++
+	}
+	return out
+}
+
+func (o *gridSearch) Observe([]Candidate, []float64) {}
+
+//
+// ------------------------ Algorithme génétique ------------------------
+//
+
+type individual struct {
+	x     []float64
+	score float64
+}
+
+// Algorithme génétique : tournoi, croisement BLX-alpha, mutation gaussienne, élitisme
+type geneticSearch struct {
+	space      searchSpace
+	rng        *rand.Rand
+	popSize    int
+	elite      int
+	mutation   float64 // écart-type de la mutation (espace normalisé)
+	population []individual
+}
+
+func newGeneticSearch(space searchSpace, rng *rand.Rand) *geneticSearch {
+	return &geneticSearch{
+		space:    space,
+		rng:      rng,
+		popSize:  16,
+		elite:    2,
+		mutation: 0.1,
+	}
+}
+
+func (o *geneticSearch) Name() string { return "genetic" }
+
+func (o *geneticSearch) Propose(n int) []Candidate {
+	var xs [][]float64
+	if len(o.population) == 0 {
+		// première génération : tirage uniforme
+		for i := 0; i < o.popSize; i++ {
+			xs = append(xs, o.space.random(o.rng))
+		}
+	} else {
+		// les élites sont conservées, on ne génère que les enfants
+		for i := o.elite; i < o.popSize; i++ {
+			a := o.tournament()
+			b := o.tournament()
+			xs = append(xs, o.mutate(o.crossover(a.x, b.x)))
+		}
+	}
+	if len(xs) > n {
+		xs = xs[:n]
+	}
+	out := make([]Candidate, len(xs))
+	for i, x := range xs {
+		out[i] = o.space.decode(x)
+	}
+	return out
+}
+
+func (o *geneticSearch) Observe(cands []Candidate, scores []float64) {
+	next := make([]individual, 0, o.popSize)
+	// élites de la génération précédente
+	for i := 0; i < o.elite && i < len(o.population); i++ {
+		next = append(next, o.population[i])
+	}
+	for i, c := range cands {
+		next = append(next, individual{x: o.space.encode(c), score: scores[i]})
+	}
+	sort.SliceStable(next, func(i, j int) bool { return next[i].score > next[j].score })
+	o.population = next
+}
+
+func (o *geneticSearch) tournament() individual {
+	best := o.population[o.rng.Intn(len(o.population))]
+	for k := 1; k < 3; k++ {
+		c := o.population[o.rng.Intn(len(o.population))]
+		if c.score > best.score {
+			best = c
+		}
+	}
+	return best
+}
+
+func (o *geneticSearch) crossover(a, b []float64) []float64 {
+	const alpha = 0.3
+	child := make([]float64, len(a))
+	for i := range a {
+		lo, hi := math.Min(a[i], b[i]), math.Max(a[i], b[i])
+		span := hi - lo
+		child[i] = lo - alpha*span + o.rng.Float64()*(1+2*alpha)*span
+	}
+	return child
+}
+
+func (o *geneticSearch) mutate(x []float64) []float64 {
+	rate := 1 / float64(len(x))
+	for i := range x {
+		if o.rng.Float64() < rate {
+			x[i] += o.rng.NormFloat64() * o.mutation
+		}
+		x[i] = clamp01(x[i])
+	}
+	return x
+}
+
+//
+// ------------------------ CMA-ES ------------------------
+//
+
+// CMA-ES (mu/mu_w, lambda) dans l'espace normalisé, bornes gérées par projection
+type cmaes struct {
+	space searchSpace
+	rng   *rand.Rand
+
+	n, lambda, mu int
+	weights       []float64
+	muEff         float64
+	cSigma, dSig  float64
+	cc, c1, cMu   float64
+	chiN          float64
+
+	mean   []float64
+	sigma  float64
+	pSigma []float64
+	pc     []float64
+	C      [][]float64
+	B      [][]float64 // vecteurs propres de C (colonnes)
+	D      []float64   // racines des valeurs propres de C
+	gen    int
+}
+
+func newCMAES(space searchSpace, rng *rand.Rand) *cmaes {
+	n := space.size()
+	nf := float64(n)
+	lambda := 4 + int(3*math.Log(nf))
+	mu := lambda / 2
+
+	weights := make([]float64, mu)
+	var sum, sumSq float64
+	for i := range weights {
+		weights[i] = math.Log(float64(mu)+0.5) - math.Log(float64(i+1))
+		sum += weights[i]
+	}
+	for i := range weights {
+		weights[i] /= sum
+		sumSq += weights[i] * weights[i]
+	}
+	muEff := 1 / sumSq
+
+	o := &cmaes{
+		space:   space,
+		rng:     rng,
+		n:       n,
+		lambda:  lambda,
+		mu:      mu,
+		weights: weights,
+		muEff:   muEff,
+		cSigma:  (muEff + 2) / (nf + muEff + 5),
+		cc:      (4 + muEff/nf) / (nf + 4 + 2*muEff/nf),
+		c1:      2 / ((nf+1.3)*(nf+1.3) + muEff),
+		chiN:    math.Sqrt(nf) * (1 - 1/(4*nf) + 1/(21*nf*nf)),
+		sigma:   0.3,
+		mean:    make([]float64, n),
+		pSigma:  make([]float64, n),
+		pc:      make([]float64, n),
+		D:       make([]float64, n),
+	}
+	o.dSig = 1 + 2*math.Max(0, math.Sqrt((muEff-1)/(nf+1))-1) + o.cSigma
+	o.cMu = math.Min(1-o.c1, 2*(muEff-2+1/muEff)/((nf+2)*(nf+2)+muEff))
+
+	o.C = identity(n)
+	o.B = identity(n)
+	for i := range o.mean {
+		o.mean[i] = 0.5
+		o.D[i] = 1
+	}
+	return o
+}
+
+func (o *cmaes) Name() string { return "cmaes" }
+
+func (o *cmaes) Propose(n int) []Candidate {
+	count := o.lambda
+	if count > n {
+		count = n
+	}
+	out := make([]Candidate, count)
+	for k := range out {
+		z := make([]float64, o.n)
+		for i := range z {
+			z[i] = o.rng.NormFloat64() * o.D[i]
+		}
+		x := make([]float64, o.n)
+		for i := range x {
+			x[i] = o.mean[i]
+			for j := range z {
+				x[i] += o.sigma * o.B[i][j] * z[j]
+			}
+		}
+		out[k] = o.space.decode(x)
+	}
+	return out
+}
+
+func (o *cmaes) Observe(cands []Candidate, scores []float64) {
+	if len(cands) < o.mu {
+		return // génération incomplète (fin de budget)
+	}
+	nf := float64(o.n)
+
+	// tri par score décroissant (on maximise)
+	idx := make([]int, len(cands))
+	for i := range idx {
+		idx[i] = i
+	}
+	sort.SliceStable(idx, func(a, b int) bool { return scores[idx[a]] > scores[idx[b]] })
+
+	// on travaille sur les points réellement évalués (projetés dans les bornes)
+	xs := make([][]float64, o.mu)
+	for i := 0; i < o.mu; i++ {
+		xs[i] = o.space.encode(cands[idx[i]])
+	}
+
+	oldMean := append([]float64(nil), o.mean...)
+	for i := range o.mean {
+		o.mean[i] = 0
+		for k, x := range xs {
+			o.mean[i] += o.weights[k] * x[i]
+		}
+	}
+
+	yw := make([]float64, o.n)
+	for i := range yw {
+		yw[i] = (o.mean[i] - oldMean[i]) / o.sigma
+	}
+
+	// C^{-1/2} * yw = B D^{-1} B^T yw
+	bty := make([]float64, o.n)
+	for j := 0; j < o.n; j++ {
+		for i := 0; i < o.n; i++ {
+			bty[j] += o.B[i][j] * yw[i]
+		}
+		bty[j] /= o.D[j]
+	}
+	cs := math.Sqrt(o.cSigma * (2 - o.cSigma) * o.muEff)
+	for i := range o.pSigma {
+		var v float64
+		for j := 0; j < o.n; j++ {
+			v += o.B[i][j] * bty[j]
+		}
+		o.pSigma[i] = (1-o.cSigma)*o.pSigma[i] + cs*v
+	}
+
+	o.gen++
+	psNorm := norm(o.pSigma)
+	hSigma := 0.0
+	if psNorm/math.Sqrt(1-math.Pow(1-o.cSigma, 2*float64(o.gen))) < (1.4+2/(nf+1))*o.chiN {
+		hSigma = 1
+	}
+
+	ccs := math.Sqrt(o.cc * (2 - o.cc) * o.muEff)
+	for i := range o.pc {
+		o.pc[i] = (1-o.cc)*o.pc[i] + hSigma*ccs*yw[i]
+	}
+
+	// mise à jour de la covariance (rang 1 + rang mu)
+	for i := 0; i < o.n; i++ {
+		for j := 0; j < o.n; j++ {
+			rankMu := 0.0
+			for k, x := range xs {
+				yi := (x[i] - oldMean[i]) / o.sigma
+				yj := (x[j] - oldMean[j]) / o.sigma
+				rankMu += o.weights[k] * yi * yj
+			}
+			rankOne := o.pc[i]*o.pc[j] + (1-hSigma)*o.cc*(2-o.cc)*o.C[i][j]
+			o.C[i][j] = (1-o.c1-o.cMu)*o.C[i][j] + o.c1*rankOne + o.cMu*rankMu
+		}
+	}
+
+	o.sigma *= math.Exp((o.cSigma / o.dSig) * (psNorm/o.chiN - 1))
+	o.sigma = math.Min(o.sigma, 1)
+
+	eigenvalues, vectors := jacobiEigen(o.C)
+	o.B = vectors
+	for i, ev := range eigenvalues {
+		o.D[i] = math.Sqrt(math.Max(ev, 1e-20))
+	}
+}
+
+func identity(n int) [][]float64 {
+	m := make([][]float64, n)
+	for i := range m {
+		m[i] = make([]float64, n)
+		m[i][i] = 1
+	}
+	return m
+}
+
+func norm(v []float64) float64 {
+	var s float64
+	for _, x := range v {
+		s += x * x
+	}
+	return math.Sqrt(s)
+}
+
+// Décomposition propre d'une matrice symétrique (méthode de Jacobi).
+// Retourne les valeurs propres et les vecteurs propres en colonnes.
+func jacobiEigen(m [][]float64) ([]float64, [][]float64) {
+	n := len(m)
+	a := make([][]float64, n)
+	for i := range a {
+		a[i] = append([]float64(nil), m[i]...)
+	}
+	v := identity(n)
+
+	for sweep := 0; sweep < 50; sweep++ {
+		off := 0.0
+		for i := 0; i < n; i++ {
+			for j := i + 1; j < n; j++ {
+				off += a[i][j] * a[i][j]
+			}
+		}
+		if off < 1e-22 {
+			break
+		}
+		for p := 0; p < n; p++ {
+			for q := p + 1; q < n; q++ {
+				if math.Abs(a[p][q]) < 1e-300 {
+					continue
+				}
+				theta := (a[q][q] - a[p][p]) / (2 * a[p][q])
+				t := math.Copysign(1, theta) / (math.Abs(theta) + math.Sqrt(theta*theta+1))
+				c := 1 / math.Sqrt(t*t+1)
+				s := t * c
+				for k := 0; k < n; k++ {
+					akp, akq := a[k][p], a[k][q]
+					a[k][p] = c*akp - s*akq
+					a[k][q] = s*akp + c*akq
+				}
+				for k := 0; k < n; k++ {
+					apk, aqk := a[p][k], a[q][k]
+					a[p][k] = c*apk - s*aqk
+					a[q][k] = s*apk + c*aqk
+				}
+				for k := 0; k < n; k++ {
+					vkp, vkq := v[k][p], v[k][q]
+					v[k][p] = c*vkp - s*vkq
+					v[k][q] = s*vkp + c*vkq
+				}
+			}
+		}
+	}
+
+	eigenvalues := make([]float64, n)
+	for i := range eigenvalues {
+		eigenvalues[i] = a[i][i]
+	}
+	return eigenvalues, v
+}
@@ -0,0 +1,90 @@
+package main
+
+import (
+	"math"
+	"math/rand"
+	"sort"
+	"testing"
+)
+
+func TestJacobiEigen(t *testing.T) {
+	// valeurs propres connues : 1, puis 2 et 4 pour le bloc [[3 1] [1 3]]
+	m := [][]float64{
+		{1, 0, 0},
+		{0, 3, 1},
+		{0, 1, 3},
+	}
+	values, vectors := jacobiEigen(m)
+	got := append([]float64(nil), values...)
+	sort.Float64s(got)
+	for i, want := range []float64{1, 2, 4} {
+		if math.Abs(got[i]-want) > 1e-9 {
+			t.Fatalf("eigenvalues = %v, want [1 2 4]", got)
+		}
+	}
+	// chaque colonne v vérifie m v = lambda v
+	for k, lambda := range values {
+		for i := range m {
+			var mv float64
+			for j := range m {
+				mv += m[i][j] * vectors[j][k]
+			}
+			if math.Abs(mv-lambda*vectors[i][k]) > 1e-9 {
+				t.Fatalf("column %d is not an eigenvector for %v", k, lambda)
+			}
+		}
+	}
+}
+
+// Fait tourner l'optimiseur comme RunBatchTraining et compte les évaluations
+func countEvaluations(opt Optimizer, budget int) int {
+	evaluated := 0
+	for evaluated < budget {
+		batch := opt.Propose(budget - evaluated)
+		if len(batch) == 0 {
+			break
+		}
+		scores := make([]float64, len(batch))
+		for i, c := range batch {
+			scores[i] = -math.Abs(c.Policy.RayonAide - 250)
+		}
+		opt.Observe(batch, scores)
+		evaluated += len(batch)
+	}
+	return evaluated
+}
+
+func TestOptimizersRespectBudget(t *testing.T) {
+	for _, name := range []string{"grid", "genetic", "cmaes"} {
+		for _, budget := range []int{7, 20, 80} {
+			opt, err := newOptimizer(name, defaultSearchSpace(), budget, rand.New(rand.NewSource(1)))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if n := countEvaluations(opt, budget); n > budget {
+				t.Errorf("%s with budget %d evaluated %d points", name, budget, n)
+			}
+		}
+	}
+}
+
+func TestGridFillsBudget(t *testing.T) {
+	space := defaultSearchSpace()
+	for _, tc := range []struct{ budget, want int }{{80, 72}, {20, 16}, {32, 32}} {
+		g := newGridSearch(space, tc.budget)
+		if len(g.points) != tc.want {
+			t.Errorf("budget %d: %d points, want %d", tc.budget, len(g.points), tc.want)
+		}
+	}
+	// sous 2^5, chaque dimension varie encore sauf une
+	levels := gridLevels(space, 20)
+	varied := 0
+	for _, l := range levels {
+		if l > 1 {
+			varied++
+		}
+	}
+	if varied != 4 {
+		t.Errorf("budget 20: levels %v", levels)
+	}
+}
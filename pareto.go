@@ -0,0 +1,173 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+)
+
+// Objectifs moyens d'une combinaison (rescueRate à maximiser, le reste à minimiser)
+type Objectives struct {
+	RescueRate float64 `json:"rescueRate"`
+	TotalTime  float64 `json:"totalTime"`
+	Energy     float64 `json:"energy"`
+	FleetCost  float64 `json:"fleetCost"`
+}
+
+// Vecteur "plus c'est grand mieux c'est", pour la dominance et la normalisation
+func (o Objectives) gains() []float64 {
+	return []float64{o.RescueRate, -o.TotalTime, -o.Energy, -o.FleetCost}
+}
+
+// a domine b : au moins aussi bon partout, strictement meilleur sur un objectif
+func dominates(a, b Objectives) bool {
+	ga, gb := a.gains(), b.gains()
+	strictly := false
+	for i := range ga {
+		if ga[i] < gb[i] {
+			return false
+		}
+		if ga[i] > gb[i] {
+			strictly = true
+		}
+	}
+	return strictly
+}
+
+// Un point du front : la policy et ses performances
+type ParetoPoint struct {
+	Candidate  int                 `json:"candidate"` // index du candidat dans le batch
+	Policy     LearnedPolicyConfig `json:"policy"`
+	Objectives Objectives          `json:"objectives"`
+	Score      float64             `json:"score"` // score scalaire (ScoringConfig) pour référence
+}
+
+// Fichier écrit à côté de best_policy.json
+type ParetoFront struct {
+	Objectives []string      `json:"objectives"`
+	Points     []ParetoPoint `json:"points"`
+}
+
+// Sélecteurs nommés acceptés par ParetoFront.pick (en plus d'un index)
+var paretoSelectors = []string{"knee", "max-rescue", "min-time", "min-energy", "min-cost"}
+
+// Front non dominé, trié par coût de flotte puis par temps
+func paretoFront(points []ParetoPoint) ParetoFront {
+	var front []ParetoPoint
+	for i, p := range points {
+		dominated := false
+		for j, q := range points {
+			if i != j && dominates(q.Objectives, p.Objectives) {
+				dominated = true
+				break
+			}
+		}
+		if !dominated {
+			front = append(front, p)
+		}
+	}
+	sort.SliceStable(front, func(i, j int) bool {
+		a, b := front[i].Objectives, front[j].Objectives
+		if a.FleetCost != b.FleetCost {
+			return a.FleetCost < b.FleetCost
+		}
+		return a.TotalTime < b.TotalTime
+	})
+	return ParetoFront{
+		Objectives: []string{"rescueRate (max)", "totalTime (min)", "energy (min)", "fleetCost (min)"},
+		Points:     front,
+	}
+}
+
+// Choisit un point du front : index ou sélecteur nommé (voir paretoSelectors)
+func (f ParetoFront) pick(selector string) (ParetoPoint, error) {
+	if len(f.Points) == 0 {
+		return ParetoPoint{}, fmt.Errorf("front de Pareto vide")
+	}
+	if i, err := strconv.Atoi(selector); err == nil {
+		if i < 0 || i >= len(f.Points) {
+			return ParetoPoint{}, fmt.Errorf("point %d hors du front (0..%d)", i, len(f.Points)-1)
+		}
+		return f.Points[i], nil
+	}
+
+	best := func(better func(a, b Objectives) bool) ParetoPoint {
+		p := f.Points[0]
+		for _, q := range f.Points[1:] {
+			if better(q.Objectives, p.Objectives) {
+				p = q
+			}
+		}
+		return p
+	}
+	switch selector {
+	case "max-rescue":
+		return best(func(a, b Objectives) bool { return a.RescueRate > b.RescueRate }), nil
+	case "min-time":
+		return best(func(a, b Objectives) bool { return a.TotalTime < b.TotalTime }), nil
+	case "min-energy":
+		return best(func(a, b Objectives) bool { return a.Energy < b.Energy }), nil
+	case "min-cost":
+		return best(func(a, b Objectives) bool { return a.FleetCost < b.FleetCost }), nil
+	case "", "knee":
+		return f.knee(), nil
+	}
+	return ParetoPoint{}, fmt.Errorf("sélecteur de point inconnu %q (index ou %v)", selector, paretoSelectors)
+}
+
+// Point le plus proche de l'idéal, objectifs normalisés sur le front
+func (f ParetoFront) knee() ParetoPoint {
+	n := len(f.Points[0].Objectives.gains())
+	lo := make([]float64, n)
+	hi := make([]float64, n)
+	for k := range lo {
+		lo[k], hi[k] = math.Inf(1), math.Inf(-1)
+	}
+	for _, p := range f.Points {
+		for k, g := range p.Objectives.gains() {
+			lo[k] = math.Min(lo[k], g)
+			hi[k] = math.Max(hi[k], g)
+		}
+	}
+
+	bestIdx, bestDist := 0, math.Inf(1)
+	for i, p := range f.Points {
+		var d float64
+		for k, g := range p.Objectives.gains() {
+			if hi[k] > lo[k] {
+				gap := 1 - (g-lo[k])/(hi[k]-lo[k])
+				d += gap * gap
+			}
+		}
+		if d < bestDist {
+			bestIdx, bestDist = i, d
+		}
+	}
+	return f.Points[bestIdx]
+}
+
+// Chemin du front par défaut : pareto_front.json à côté de la policy
+func defaultParetoPath(policyPath string) string {
+	return filepath.Join(filepath.Dir(policyPath), "pareto_front.json")
+}
+
+func saveParetoFront(path string, f ParetoFront) error {
+	return writeJSONOutput(path, f)
+}
+
+func loadParetoFront(path string) (ParetoFront, error) {
+	var f ParetoFront
+	file, err := os.Open(path)
+	if err != nil {
+		return f, err
+	}
+	defer file.Close()
+	if err := json.NewDecoder(file).Decode(&f); err != nil {
+		return f, fmt.Errorf("%s invalide : %w", path, err)
+	}
+	return f, nil
+}
@@ -0,0 +1,54 @@
+package main
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestParetoFront(t *testing.T) {
+	obj := func(rescue, time float64) Objectives {
+		return Objectives{RescueRate: rescue, TotalTime: time, Energy: 10, FleetCost: 1000}
+	}
+	points := []ParetoPoint{
+		{Candidate: 0, Objectives: obj(1.0, 200)}, // A : sauve tout, lentement
+		{Candidate: 1, Objectives: obj(0.9, 110)}, // B : compromis
+		{Candidate: 2, Objectives: obj(0.5, 100)}, // C : rapide
+		{Candidate: 3, Objectives: obj(0.9, 150)}, // dominé par B
+		{Candidate: 4, Objectives: obj(0.4, 100)}, // dominé par C
+	}
+
+	if !dominates(points[1].Objectives, points[3].Objectives) {
+		t.Error("B should dominate D")
+	}
+	if dominates(points[1].Objectives, points[1].Objectives) {
+		t.Error("a point dominates itself")
+	}
+	if dominates(points[0].Objectives, points[2].Objectives) || dominates(points[2].Objectives, points[0].Objectives) {
+		t.Error("A and C are a trade-off, neither dominates")
+	}
+
+	front := paretoFront(points)
+	var got []int
+	for _, p := range front.Points {
+		got = append(got, p.Candidate)
+	}
+	// même coût : tri par temps
+	if want := []int{2, 1, 0}; !slices.Equal(got, want) {
+		t.Fatalf("front = %v, want %v", got, want)
+	}
+
+	if k := front.knee(); k.Candidate != 1 {
+		t.Errorf("knee = candidate %d, want the compromise 1", k.Candidate)
+	}
+	for sel, want := range map[string]int{"max-rescue": 0, "min-time": 2, "1": 1, "knee": 1} {
+		p, err := front.pick(sel)
+		if err != nil || p.Candidate != want {
+			t.Errorf("pick(%q) = %d, %v; want %d", sel, p.Candidate, err, want)
+		}
+	}
+	for _, sel := range []string{"3", "-1", "fastest"} {
+		if _, err := front.pick(sel); err == nil {
+			t.Errorf("pick(%q) accepted", sel)
+		}
+	}
+}
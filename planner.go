@@ -0,0 +1,319 @@
+package main
+
+import (
+	"container/heap"
+	"math"
+	"sync"
+)
+
+//
+// ------------------------ Planification de trajectoire ------------------------
+//
+// Theta* sur une grille d'occupation : A* 8-connexe dont chaque noeud peut
+// prendre pour parent le parent de son prédécesseur quand la ligne de vue est
+// libre, ce qui donne des chemins à angles quelconques. Les cellules proches
+// d'une zone sont marquées occupées pour garder une marge aux bords.
+
+const (
+	plannerCell      = 20.0 // taille d'une cellule (même pas que la heatmap)
+	plannerClearance = 10.0 // marge minimale entre une cellule libre et une zone
+	waypointReached  = 5.0  // distance à laquelle un point de passage est atteint
+)
+
+type Planner struct {
+	zones   []Zone
+	w, h    int
+	blocked []bool // w*h, indice i + j*w
+
+	mu    sync.Mutex
+	trees map[Point]*routeTree // chemins vers les buts fixes (stations), calculés à la demande
+}
+
+// Chemins de toutes les cellules vers un même but
+type routeTree struct {
+	gx, gy float64
+	goal   int
+	parent map[int]int // cellule suivante vers le but
+}
+
+// Grille d'occupation des zones ; nil si la carte n'a pas de zone
+func newPlanner(cfg SimConfig) *Planner {
+	if len(cfg.Zones) == 0 {
+		return nil
+	}
+	w := int(math.Ceil(cfg.Width / plannerCell))
+	h := int(math.Ceil(cfg.Height / plannerCell))
+	p := &Planner{zones: cfg.Zones, w: w, h: h, blocked: make([]bool, w*h), trees: map[Point]*routeTree{}}
+	for j := 0; j < h; j++ {
+		for i := 0; i < w; i++ {
+			x, y := p.center(i + j*w)
+			p.blocked[i+j*w] = insideZones(cfg.Zones, x, y) || nearZoneEdge(cfg.Zones, x, y, plannerClearance)
+		}
+	}
+	return p
+}
+
+func (p *Planner) center(n int) (float64, float64) {
+	return (float64(n%p.w) + 0.5) * plannerCell, (float64(n/p.w) + 0.5) * plannerCell
+}
+
+func (p *Planner) cellOf(x, y float64) int {
+	i := clampInt(int(x/plannerCell), 0, p.w-1)
+	j := clampInt(int(y/plannerCell), 0, p.h-1)
+	return i + j*p.w
+}
+
+// Ligne de vue libre entre deux points
+func (p *Planner) visible(ax, ay, bx, by float64) bool {
+	for _, z := range p.zones {
+		if z.contains(bx, by) || z.crosses(ax, ay, bx, by) {
+			return false
+		}
+	}
+	return true
+}
+
+// Chemin de (sx, sy) à (gx, gy) : points de passage sans le départ, but compris.
+// false si le but est inatteignable.
+func (p *Planner) plan(sx, sy, gx, gy float64) ([]Point, bool) {
+	start, goal := p.cellOf(sx, sy), p.cellOf(gx, gy)
+	if start == goal {
+		// même cellule : la grille ne voit pas mieux que la ligne droite
+		return []Point{{X: gx, Y: gy}}, true
+	}
+	pos := func(n int) (float64, float64) {
+		switch n {
+		case start:
+			return sx, sy
+		case goal:
+			return gx, gy
+		}
+		return p.center(n)
+	}
+	parent, ok := p.search(start, goal, pos, func(x, y float64) float64 { return distance(x, y, gx, gy) })
+	if !ok {
+		return nil, false
+	}
+	return p.path(parent, start, goal, pos), true
+}
+
+// Chemin de (sx, sy) vers un but fixe, lu dans l'arbre des chemins de ce but :
+// même Theta* que plan, mais la recherche n'est faite qu'une fois par but.
+// Sert aux estimations répétées à chaque pas (énergie de retour aux stations).
+func (p *Planner) routeTo(sx, sy, gx, gy float64) ([]Point, bool) {
+	t := p.tree(gx, gy)
+	n := p.cellOf(sx, sy)
+	if _, ok := t.parent[n]; !ok {
+		return nil, false
+	}
+	route := []Point{}
+	for n != t.goal {
+		n = t.parent[n]
+		x, y := p.center(n)
+		if n == t.goal {
+			x, y = gx, gy
+		}
+		route = append(route, Point{X: x, Y: y})
+	}
+	if len(route) == 0 {
+		return []Point{{X: gx, Y: gy}}, true
+	}
+	// raccourcis depuis la position exacte du départ
+	for len(route) > 1 && p.visible(sx, sy, route[1].X, route[1].Y) {
+		route = route[1:]
+	}
+	return route, true
+}
+
+func (p *Planner) tree(gx, gy float64) *routeTree {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if t, ok := p.trees[Point{X: gx, Y: gy}]; ok {
+		return t
+	}
+	goal := p.cellOf(gx, gy)
+	pos := func(n int) (float64, float64) {
+		if n == goal {
+			return gx, gy
+		}
+		return p.center(n)
+	}
+	// les chemins sont symétriques : on cherche depuis le but vers toutes les cellules
+	parent, _ := p.search(goal, -1, pos, func(x, y float64) float64 { return 0 })
+	t := &routeTree{gx: gx, gy: gy, goal: goal, parent: parent}
+	p.trees[Point{X: gx, Y: gy}] = t
+	return t
+}
+
+// Theta* depuis la cellule start, jusqu'à goal (-1 : toutes les cellules
+// atteignables). Les cellules occupées ne sont jamais traversées ; sans but,
+// elles sont atteintes comme des feuilles, car un drone peut s'y trouver.
+func (p *Planner) search(start, goal int, pos func(int) (float64, float64), h func(x, y float64) float64) (map[int]int, bool) {
+	sx, sy := pos(start)
+	g := map[int]float64{start: 0}
+	parent := map[int]int{start: start}
+	closed := map[int]bool{}
+	open := &nodeQueue{}
+	heap.Push(open, nodeItem{n: start, f: h(sx, sy)})
+
+	for open.Len() > 0 {
+		cur := heap.Pop(open).(nodeItem).n
+		if cur == goal {
+			return parent, true
+		}
+		if closed[cur] {
+			continue
+		}
+		closed[cur] = true
+		if goal < 0 && cur != start && p.blocked[cur] {
+			continue
+		}
+		cx, cy := pos(cur)
+		par := parent[cur]
+		px, py := pos(par)
+
+		ci, cj := cur%p.w, cur/p.w
+		for dj := -1; dj <= 1; dj++ {
+			for di := -1; di <= 1; di++ {
+				ni, nj := ci+di, cj+dj
+				if (di == 0 && dj == 0) || ni < 0 || nj < 0 || ni >= p.w || nj >= p.h {
+					continue
+				}
+				n := ni + nj*p.w
+				if closed[n] || (p.blocked[n] && n != goal && goal >= 0) {
+					continue
+				}
+				nx, ny := pos(n)
+				// Theta* : raccourci par le parent si la ligne de vue le permet
+				from, fx, fy := cur, cx, cy
+				if par != cur && p.visible(px, py, nx, ny) {
+					from, fx, fy = par, px, py
+				} else if !p.visible(cx, cy, nx, ny) {
+					continue
+				}
+				cost := g[from] + distance(fx, fy, nx, ny)
+				if old, ok := g[n]; ok && cost >= old {
+					continue
+				}
+				g[n] = cost
+				parent[n] = from
+				heap.Push(open, nodeItem{n: n, f: cost + h(nx, ny)})
+			}
+		}
+	}
+	return parent, goal < 0
+}
+
+func (p *Planner) path(parent map[int]int, start, goal int, pos func(int) (float64, float64)) []Point {
+	var rev []Point
+	for n := goal; n != start; n = parent[n] {
+		x, y := pos(n)
+		rev = append(rev, Point{X: x, Y: y})
+	}
+	route := make([]Point, len(rev))
+	for i, pt := range rev {
+		route[len(rev)-1-i] = pt
+	}
+	return route
+}
+
+// File de priorité de l'A* (f croissant)
+type nodeItem struct {
+	n int
+	f float64
+}
+
+type nodeQueue []nodeItem
+
+func (q nodeQueue) Len() int           { return len(q) }
+func (q nodeQueue) Less(i, j int) bool { return q[i].f < q[j].f }
+func (q nodeQueue) Swap(i, j int)      { q[i], q[j] = q[j], q[i] }
+func (q *nodeQueue) Push(x any)        { *q = append(*q, x.(nodeItem)) }
+
+func (q *nodeQueue) Pop() any {
+	old := *q
+	it := old[len(old)-1]
+	*q = old[:len(old)-1]
+	return it
+}
+
+// Point à moins de r d'un bord de zone
+func nearZoneEdge(zones []Zone, x, y, r float64) bool {
+	for _, z := range zones {
+		n := len(z.Polygon)
+		for i, j := 0, n-1; i < n; j, i = i, i+1 {
+			a, b := z.Polygon[j], z.Polygon[i]
+			if pointSegmentDistance(x, y, a.X, a.Y, b.X, b.Y) < r {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func pointSegmentDistance(px, py, ax, ay, bx, by float64) float64 {
+	dx, dy := bx-ax, by-ay
+	l2 := dx*dx + dy*dy
+	if l2 == 0 {
+		return distance(px, py, ax, ay)
+	}
+	t := math.Max(0, math.Min(1, ((px-ax)*dx+(py-ay)*dy)/l2))
+	return distance(px, py, ax+t*dx, ay+t*dy)
+}
+
+func clampInt(v, lo, hi int) int {
+	if v < lo {
+		return lo
+	}
+	if v > hi {
+		return hi
+	}
+	return v
+}
+
+// Prochain point de passage du drone vers sa cible. Le chemin est gardé dans
+// dr.Route et recalculé quand la cible change ou que la ligne de vue vers le
+// prochain point est coupée ; sans zone, ou si le but est inatteignable, on
+// vise la cible en ligne droite.
+func nextWaypoint(env *Environment, dr *Drone) (float64, float64) {
+	target := Point{X: dr.TargetX, Y: dr.TargetY}
+	p := env.planner
+	if p == nil {
+		if len(dr.Route) != 1 || dr.Route[0] != target {
+			dr.Route = []Point{target}
+		}
+		return target.X, target.Y
+	}
+
+	// cache valide : même cible et prochain point toujours visible
+	valid := len(dr.Route) > 0 && dr.Route[len(dr.Route)-1] == target &&
+		p.visible(dr.X, dr.Y, dr.Route[0].X, dr.Route[0].Y)
+	if !valid {
+		if p.visible(dr.X, dr.Y, target.X, target.Y) {
+			dr.Route = []Point{target}
+		} else if route, ok := p.plan(dr.X, dr.Y, target.X, target.Y); ok && len(route) > 0 {
+			dr.Route = route
+		} else {
+			dr.Route = []Point{target}
+		}
+	}
+
+	// points atteints, ou dépassables car le suivant est déjà en vue
+	for len(dr.Route) > 1 &&
+		(distance(dr.X, dr.Y, dr.Route[0].X, dr.Route[0].Y) <= waypointReached ||
+			p.visible(dr.X, dr.Y, dr.Route[1].X, dr.Route[1].Y)) {
+		dr.Route = dr.Route[1:]
+	}
+	return dr.Route[0].X, dr.Route[0].Y
+}
+
+// Oriente la vitesse du drone vers (x, y)
+func (dr *Drone) flyToward(x, y float64) {
+	dx, dy := x-dr.X, y-dr.Y
+	dist := math.Hypot(dx, dy)
+	if dist == 0 {
+		return
+	}
+	dr.Vx = dx / dist * dr.Speed
+	dr.Vy = dy / dist * dr.Speed
+}
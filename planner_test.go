@@ -0,0 +1,65 @@
+package main
+
+import "testing"
+
+func TestPlannerRoutesAroundObstacle(t *testing.T) {
+	cfg := defaultConfig()
+	cfg.Width, cfg.Height = 1000, 800
+	// mur vertical, ouvert en haut et en bas
+	cfg.Zones = normalizeZones([]Zone{{Kind: ZoneObstacle, Polygon: []Point{{480, 150}, {520, 150}, {520, 650}, {480, 650}}}})
+	p := newPlanner(cfg)
+
+	sx, sy, gx, gy := 300.0, 400.0, 700.0, 400.0
+	if p.visible(sx, sy, gx, gy) {
+		t.Fatal("the wall should block the straight line")
+	}
+	route, ok := p.plan(sx, sy, gx, gy)
+	if !ok || len(route) < 2 {
+		t.Fatalf("plan = %v, %v; want a route with a detour", route, ok)
+	}
+	if last := route[len(route)-1]; last.X != gx || last.Y != gy {
+		t.Errorf("route ends at %v, want the goal", last)
+	}
+	length := 0.0
+	x, y := sx, sy
+	for _, pt := range route {
+		if !p.visible(x, y, pt.X, pt.Y) {
+			t.Fatalf("leg (%v, %v) -> %v goes through the wall", x, y, pt)
+		}
+		length += distance(x, y, pt.X, pt.Y)
+		x, y = pt.X, pt.Y
+	}
+	// Theta* : angles quelconques, peu de points et un détour proche de l'optimal
+	// (deux segments vers un coin du mur : 2 x hypot(200, 250) ≈ 640)
+	if len(route) > 4 || length > 700 {
+		t.Errorf("route of %d points and length %.0f, want a near-optimal any-angle path", len(route), length)
+	}
+
+	if _, ok := p.plan(sx, sy, 500, 400); ok {
+		t.Error("a goal inside the obstacle was reached")
+	}
+	if newPlanner(defaultConfig()) != nil {
+		t.Error("planner built for a map without zones")
+	}
+}
+
+func TestPlannerSameCellBehindThinWall(t *testing.T) {
+	cfg := defaultConfig()
+	cfg.Zones = normalizeZones([]Zone{{Kind: ZoneObstacle, Polygon: []Point{{49, 0}, {51, 0}, {51, 100}, {49, 100}}}})
+	p := newPlanner(cfg)
+
+	// départ et but dans la même cellule, séparés par le mur
+	if p.cellOf(45, 50) != p.cellOf(55, 50) || p.visible(45, 50, 55, 50) {
+		t.Fatal("setup: want one cell with the wall in between")
+	}
+	route, ok := p.plan(45, 50, 55, 50)
+	if !ok || len(route) != 1 || route[0] != (Point{X: 55, Y: 50}) {
+		t.Errorf("plan = %v, %v; want the goal alone", route, ok)
+	}
+
+	env := &Environment{Config: cfg, planner: p}
+	dr := &Drone{X: 45, Y: 50, TargetX: 55, TargetY: 50}
+	if x, y := nextWaypoint(env, dr); x != 55 || y != 50 {
+		t.Errorf("next waypoint (%v, %v), want the target", x, y)
+	}
+}
@@ -0,0 +1,285 @@
+package main
+
+import (
+	"compress/gzip"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+//
+// ------------------------ Enregistrement et relecture ------------------------
+//
+// Un replay est un fichier JSON lines compressé en gzip (.jsonl.gz) : une ligne
+// d'en-tête, puis une image par pas enregistré. Les images sont des deltas
+// (mêmes deltas que le flux /stream) avec une keyframe toutes les
+// replayKeyframeEvery images pour pouvoir se positionner n'importe où.
+
+const (
+	replayVersion       = 1
+	replayKeyframeEvery = 50 // images entre deux keyframes
+	replayExt           = ".jsonl.gz"
+)
+
+// Première ligne du fichier
+type ReplayHeader struct {
+	Version       int       `json:"version"`
+	Every         int       `json:"every"` // un pas enregistré tous les Every pas
+	KeyframeEvery int       `json:"keyframeEvery"`
+	Recorded      time.Time `json:"recorded"`
+	Config        SimConfig `json:"config"`
+}
+
+// Une image : keyframe ou delta (Seq = numéro de l'image)
+type ReplayFrame struct {
+	Keyframe *Keyframe   `json:"keyframe,omitempty"`
+	Delta    *StateDelta `json:"delta,omitempty"`
+}
+
+type replayRecorder struct {
+	path   string
+	file   *os.File
+	gz     *gzip.Writer
+	enc    *json.Encoder
+	every  int
+	ticks  int
+	frames int64
+	last   Environment
+}
+
+func newReplayRecorder(path string, every int, env *Environment) (*replayRecorder, error) {
+	if every < 1 {
+		every = 1
+	}
+	f, err := os.Create(path)
+	if err != nil {
+		return nil, err
+	}
+	gz := gzip.NewWriter(f)
+	r := &replayRecorder{path: path, file: f, gz: gz, enc: json.NewEncoder(gz), every: every}
+	header := ReplayHeader{
+		Version:       replayVersion,
+		Every:         every,
+		KeyframeEvery: replayKeyframeEvery,
+		Recorded:      time.Now(),
+		Config:        env.Config,
+	}
+	if err := r.enc.Encode(header); err != nil {
+		r.close()
+		return nil, err
+	}
+	// image 0 : l'état au début de l'enregistrement
+	if err := r.write(env); err != nil {
+		r.close()
+		return nil, err
+	}
+	return r, nil
+}
+
+// Appelé après chaque pas ; la dernière image d'un run terminé est toujours écrite
+func (r *replayRecorder) record(env *Environment) error {
+	r.ticks++
+	if r.ticks%r.every != 0 && !env.Finished {
+		return nil
+	}
+	return r.write(env)
+}
+
+func (r *replayRecorder) write(env *Environment) error {
+	var frame ReplayFrame
+	if r.frames%replayKeyframeEvery == 0 || !sameShape(&r.last, env) {
+		frame.Keyframe = &Keyframe{Seq: r.frames, State: *env}
+	} else {
+		d := diffState(&r.last, env)
+		if d == nil {
+			return nil
+		}
+		d.Seq = r.frames
+		frame.Delta = d
+	}
+	if err := r.enc.Encode(frame); err != nil {
+		return err
+	}
+	r.last = env.clone()
+	r.frames++
+	return nil
+}
+
+func (r *replayRecorder) close() error {
+	err := r.gz.Close()
+	if cerr := r.file.Close(); err == nil {
+		err = cerr
+	}
+	return err
+}
+
+// Démarre l'enregistrement de la simulation (remplace un enregistrement en cours)
+func (s *Simulation) startRecording(path string, every int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.stopRecordingLocked()
+	rec, err := newReplayRecorder(path, every, &s.env)
+	if err != nil {
+		return err
+	}
+	s.recorder = rec
+	return nil
+}
+
+// Arrête l'enregistrement ; renvoie le fichier et le nombre d'images ("" si aucun)
+func (s *Simulation) stopRecording() (string, int64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.stopRecordingLocked()
+}
+
+func (s *Simulation) stopRecordingLocked() (string, int64) {
+	rec := s.recorder
+	if rec == nil {
+		return "", 0
+	}
+	s.recorder = nil
+	if err := rec.close(); err != nil {
+		log.Printf("replay %s: %v", rec.path, err)
+	}
+	return rec.path, rec.frames
+}
+
+// Nom du replay en cours d'enregistrement, verrou déjà pris
+func (s *Simulation) recordingName() string {
+	if s.recorder == nil {
+		return ""
+	}
+	return filepath.Base(s.recorder.path)
+}
+
+// Enregistre le pas courant, verrou déjà pris ; une erreur d'écriture arrête l'enregistrement
+func (s *Simulation) recordLocked() {
+	if s.recorder == nil {
+		return
+	}
+	if err := s.recorder.record(&s.env); err != nil {
+		log.Printf("replay %s: %v, enregistrement arrêté", s.recorder.path, err)
+		s.stopRecordingLocked()
+	}
+}
+
+// Run headless enregistré dans path
+func recordSimulation(cfg SimConfig, maxSteps int, path string, every int) (Environment, error) {
+	s := NewSimulation(cfg)
+	if err := s.startRecording(path, every); err != nil {
+		return Environment{}, err
+	}
+	env := finishRun(s, maxSteps)
+	_, frames := s.stopRecording()
+	log.Printf("Replay written to %s (%d frames)", path, frames)
+	return env, nil
+}
+
+//
+// ------------------------ API ------------------------
+//
+
+// Dossier des replays du serveur
+type ReplayStore struct {
+	dir string
+}
+
+// Résumé d'un fichier pour GET /api/replays
+type ReplayInfo struct {
+	Name     string    `json:"name"`
+	Size     int64     `json:"size"`
+	Modified time.Time `json:"modified"`
+}
+
+func (rs ReplayStore) path(name string) (string, bool) {
+	if name == "" || name != filepath.Base(name) || !strings.HasSuffix(name, replayExt) {
+		return "", false
+	}
+	return filepath.Join(rs.dir, name), true
+}
+
+func (rs ReplayStore) newName(sessionID string) string {
+	return fmt.Sprintf("%s-%s%s", sessionID, time.Now().Format("20060102-150405"), replayExt)
+}
+
+// GET /api/replays : replays disponibles, les plus récents d'abord
+func (rs ReplayStore) handleList(w http.ResponseWriter, r *http.Request) {
+	entries, err := os.ReadDir(rs.dir)
+	if err != nil && !os.IsNotExist(err) {
+		writeAPIError(w, http.StatusInternalServerError, APIError{Code: "replay_dir", Message: err.Error()})
+		return
+	}
+	list := []ReplayInfo{}
+	for _, e := range entries {
+		if e.IsDir() || !strings.HasSuffix(e.Name(), replayExt) {
+			continue
+		}
+		info, err := e.Info()
+		if err != nil {
+			continue
+		}
+		list = append(list, ReplayInfo{Name: e.Name(), Size: info.Size(), Modified: info.ModTime()})
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].Modified.After(list[j].Modified) })
+	writeJSON(w, list)
+}
+
+// GET /api/replays/{name} : le fichier tel quel, décompressé par le navigateur
+func (rs ReplayStore) handleGet(w http.ResponseWriter, r *http.Request) {
+	path, ok := rs.path(r.PathValue("name"))
+	if !ok {
+		writeAPIError(w, http.StatusBadRequest, APIError{Code: "invalid_replay_name", Message: "nom de replay invalide"})
+		return
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		writeAPIError(w, http.StatusNotFound, APIError{Code: "unknown_replay", Message: "replay introuvable"})
+		return
+	}
+	defer f.Close()
+	w.Header().Set("Content-Type", "application/x-ndjson")
+	w.Header().Set("Content-Encoding", "gzip")
+	io.Copy(w, f)
+}
+
+// POST /api/sims/{id}/record {"every": k} : enregistre la session dans un nouveau replay
+func (rs ReplayStore) handleRecord(w http.ResponseWriter, r *http.Request, sess *Session) {
+	req := struct {
+		Every int `json:"every"`
+	}{Every: 1}
+	if !decodeBody(w, r, &req) {
+		return
+	}
+	if req.Every < 1 {
+		writeAPIError(w, http.StatusBadRequest, APIError{Code: "invalid_every", Message: "every doit être >= 1"})
+		return
+	}
+	if err := os.MkdirAll(rs.dir, 0o755); err != nil {
+		writeAPIError(w, http.StatusInternalServerError, APIError{Code: "replay_dir", Message: err.Error()})
+		return
+	}
+	name := rs.newName(sess.ID)
+	if err := sess.sim.startRecording(filepath.Join(rs.dir, name), req.Every); err != nil {
+		writeAPIError(w, http.StatusInternalServerError, APIError{Code: "replay_write", Message: err.Error()})
+		return
+	}
+	writeJSON(w, map[string]any{"recording": true, "name": name, "every": req.Every})
+}
+
+// POST /api/sims/{id}/record/stop
+func (rs ReplayStore) handleStopRecord(w http.ResponseWriter, r *http.Request, sess *Session) {
+	path, frames := sess.sim.stopRecording()
+	if path == "" {
+		writeAPIError(w, http.StatusConflict, APIError{Code: "not_recording", Message: "aucun enregistrement en cours"})
+		return
+	}
+	writeJSON(w, map[string]any{"recording": false, "name": filepath.Base(path), "frames": frames})
+}
@@ -0,0 +1,108 @@
+package main
+
+import (
+	"bufio"
+	"compress/gzip"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestReplayRoundTrip(t *testing.T) {
+	const every, steps = 3, 160
+	path := filepath.Join(t.TempDir(), "run"+replayExt)
+	s := NewSimulation(streamTestConfig(21))
+	if err := s.startRecording(path, every); err != nil {
+		t.Fatal(err)
+	}
+	// états attendus : le départ puis un pas sur every
+	want := []Environment{s.Snapshot()}
+	for i := 1; i <= steps; i++ {
+		s.step()
+		if s.Snapshot().Finished {
+			t.Fatal("simulation finished during the test")
+		}
+		if i%every == 0 {
+			want = append(want, s.Snapshot())
+		}
+	}
+	if _, frames := s.stopRecording(); frames != int64(len(want)) {
+		t.Fatalf("%d frames written, want %d", frames, len(want))
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	gz, err := gzip.NewReader(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	lines := bufio.NewScanner(gz)
+	lines.Buffer(nil, 1<<24)
+
+	if !lines.Scan() {
+		t.Fatal("empty replay")
+	}
+	var header ReplayHeader
+	if err := json.Unmarshal(lines.Bytes(), &header); err != nil {
+		t.Fatal(err)
+	}
+	if header.Version != replayVersion || header.Every != every || header.KeyframeEvery != replayKeyframeEvery ||
+		header.Config.Seed != 21 {
+		t.Errorf("header = %+v", header)
+	}
+
+	var r streamReplica
+	n := 0
+	for ; lines.Scan(); n++ {
+		var frame ReplayFrame
+		if err := json.Unmarshal(lines.Bytes(), &frame); err != nil {
+			t.Fatal(err)
+		}
+		// keyframe toutes les replayKeyframeEvery images, deltas entre deux
+		switch {
+		case n%replayKeyframeEvery == 0:
+			if frame.Keyframe == nil || frame.Keyframe.Seq != int64(n) {
+				t.Fatalf("frame %d is not a keyframe", n)
+			}
+			r.world = frame.Keyframe.State
+		case frame.Delta == nil || frame.Delta.Seq != int64(n):
+			t.Fatalf("frame %d is not the delta %d", n, n)
+		default:
+			r.apply(t, *frame.Delta)
+		}
+		if n >= len(want) {
+			t.Fatalf("more frames than recorded states")
+		}
+		checkReplica(t, "replay frame", r.world, want[n])
+	}
+	if err := lines.Err(); err != nil {
+		t.Fatal(err)
+	}
+	if n != len(want) {
+		t.Errorf("%d frames decoded, want %d", n, len(want))
+	}
+}
+
+func TestReplayStorePathRejectsTraversal(t *testing.T) {
+	rs := ReplayStore{dir: "replays"}
+	if p, ok := rs.path("run" + replayExt); !ok || p != filepath.Join("replays", "run"+replayExt) {
+		t.Errorf("path(run%s) = %q, %v", replayExt, p, ok)
+	}
+	for _, name := range []string{
+		"",
+		replayExt[1:],
+		"../secret" + replayExt,
+		"sub/run" + replayExt,
+		"/etc/passwd",
+		"run.json",
+		"..",
+	} {
+		if p, ok := rs.path(name); ok {
+			t.Errorf("path(%q) accepted as %q", name, p)
+		}
+	}
+}
@@ -0,0 +1,173 @@
+package main
+
+import (
+	"encoding/csv"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// Modes connus, dans l'ordre des colonnes CSV
+var allModes = []DroneMode{ModeSearching, ModeResponding, ModeHovering, ModeReturning, ModeWaiting, ModeCharging}
+
+// Rapport complet d'un run headless
+type RunReport struct {
+	Config    SimConfig        `json:"config"`
+	Stats     SimStats         `json:"stats"`
+	Survivors []SurvivorReport `json:"survivors"`
+	Drones    []DroneReport    `json:"drones"`
+	Events    []Event          `json:"events"` // journal de mission complet
+}
+
+type SurvivorReport struct {
+	ID      int     `json:"id"`
+	X       float64 `json:"x"`
+	Y       float64 `json:"y"`
+	Found   bool    `json:"found"`
+	FoundAt float64 `json:"foundAt"` // instant de découverte (0 si pas trouvé)
+	FoundBy int     `json:"foundBy"` // drone découvreur (-1 si pas trouvé)
+
+	DetectedAt float64 `json:"detectedAt"` // première détection, avant confirmation (0 si jamais détecté)
+	DetectedBy int     `json:"detectedBy"` // -1 si jamais détecté
+}
+
+type DroneReport struct {
+	ID            int                   `json:"id"`
+	Type          string                `json:"type"`
+	DistanceFlown float64               `json:"distanceFlown"`
+	EnergyUsed    float64               `json:"energyUsed"` // Wh
+	Recharges     int                   `json:"recharges"`
+	HelpCalls     int                   `json:"helpCalls"`
+	HelpersCalled int                   `json:"helpersCalled"`
+	ModeTime      map[DroneMode]float64 `json:"modeTime"`
+	FinalMode     DroneMode             `json:"finalMode"`
+
+	FalsePositives   int `json:"falsePositives"`
+	MissedDetections int `json:"missedDetections"`
+}
+
+// Construit le rapport à partir de l'environnement final
+func buildRunReport(env Environment) RunReport {
+	r := RunReport{
+		Config:    env.Config,
+		Stats:     env.Stats,
+		Survivors: make([]SurvivorReport, len(env.Survivors)),
+		Drones:    make([]DroneReport, len(env.Drones)),
+		Events:    env.Events,
+	}
+	if r.Events == nil {
+		r.Events = []Event{}
+	}
+	for i, s := range env.Survivors {
+		r.Survivors[i] = SurvivorReport{
+			ID:      s.ID,
+			X:       s.X,
+			Y:       s.Y,
+			Found:   s.Saved,
+			FoundAt: s.FoundAt,
+			FoundBy: s.FoundBy,
+
+			DetectedAt: s.DetectedAt,
+			DetectedBy: s.DetectedBy,
+		}
+	}
+	for i, d := range env.Drones {
+		r.Drones[i] = DroneReport{
+			ID:            d.ID,
+			Type:          d.Type,
+			DistanceFlown: d.DistanceFlown,
+			EnergyUsed:    d.EnergyUsed,
+			Recharges:     d.Recharges,
+			HelpCalls:     d.HelpCalls,
+			HelpersCalled: d.HelpersCalled,
+			ModeTime:      d.ModeTime,
+			FinalMode:     d.Mode,
+
+			FalsePositives:   d.FalsePositives,
+			MissedDetections: d.MissedDetections,
+		}
+	}
+	return r
+}
+
+// Écrit le rapport en CSV : <base>_survivors.csv, <base>_drones.csv et <base>_events.csv
+func writeRunReportCSV(base string, r RunReport) error {
+	survivors := [][]string{{"seed", "id", "x", "y", "found", "foundAt", "foundBy", "detectedAt", "detectedBy"}}
+	for _, s := range r.Survivors {
+		survivors = append(survivors, []string{
+			formatInt(r.Stats.Seed), strconv.Itoa(s.ID), formatFloat(s.X), formatFloat(s.Y),
+			strconv.FormatBool(s.Found), formatFloat(s.FoundAt), strconv.Itoa(s.FoundBy),
+			formatFloat(s.DetectedAt), strconv.Itoa(s.DetectedBy),
+		})
+	}
+	if err := writeCSVFile(base+"_survivors.csv", survivors); err != nil {
+		return err
+	}
+
+	header := []string{"seed", "id", "type", "distanceFlown", "energyUsed", "recharges", "helpCalls", "helpersCalled", "finalMode",
+		"falsePositives", "missedDetections"}
+	for _, m := range allModes {
+		header = append(header, "time_"+string(m))
+	}
+	drones := [][]string{header}
+	for _, d := range r.Drones {
+		row := []string{
+			formatInt(r.Stats.Seed), strconv.Itoa(d.ID), d.Type, formatFloat(d.DistanceFlown), formatFloat(d.EnergyUsed),
+			strconv.Itoa(d.Recharges), strconv.Itoa(d.HelpCalls), strconv.Itoa(d.HelpersCalled), string(d.FinalMode),
+			strconv.Itoa(d.FalsePositives), strconv.Itoa(d.MissedDetections),
+		}
+		for _, m := range allModes {
+			row = append(row, formatFloat(d.ModeTime[m]))
+		}
+		drones = append(drones, row)
+	}
+	if err := writeCSVFile(base+"_drones.csv", drones); err != nil {
+		return err
+	}
+
+	events := [][]string{{"seed", "seq", "time", "type", "drone", "trace", "survivor", "requester", "chargingPoint", "autonomy", "energy"}}
+	for _, ev := range r.Events {
+		autonomy, energy := "", ""
+		if ev.Type == EventReturning {
+			autonomy, energy = formatFloat(ev.Autonomy), formatFloat(ev.Energy)
+		}
+		events = append(events, []string{
+			formatInt(r.Stats.Seed), strconv.Itoa(ev.Seq), formatFloat(ev.Time), string(ev.Type), strconv.Itoa(ev.Drone),
+			formatRef(ev.Trace), formatRef(ev.Survivor), formatRef(ev.Requester), formatRef(ev.ChargingPoint),
+			autonomy, energy,
+		})
+	}
+	return writeCSVFile(base+"_events.csv", events)
+}
+
+func writeCSVFile(path string, rows [][]string) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	err = csv.NewWriter(f).WriteAll(rows)
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	return err
+}
+
+// Chemin sans extension, base des fichiers CSV
+func reportBase(path string) string {
+	if i := strings.LastIndex(path, "."); i > strings.LastIndex(path, "/") {
+		return path[:i]
+	}
+	return path
+}
+
+func formatFloat(v float64) string { return strconv.FormatFloat(v, 'f', -1, 64) }
+
+func formatInt(v int64) string { return strconv.FormatInt(v, 10) }
+
+// Champ optionnel : vide si absent
+func formatRef(v *int) string {
+	if v == nil {
+		return ""
+	}
+	return strconv.Itoa(*v)
+}
@@ -0,0 +1,115 @@
+package main
+
+import "fmt"
+
+// Fonction objectif de l'entraînement : score = somme des poids x valeurs.
+// Un poids négatif pénalise le terme (temps, énergie, coût...). Un poids absent
+// garde la valeur du profil ; un poids à 0 annule le terme.
+type ScoringConfig struct {
+	Profile         string   `json:"profile,omitempty"`         // profil de mission de base (voir scoringProfiles)
+	RescueRate      *float64 `json:"rescueRate,omitempty"`      // part des survivants sauvés (0..1)
+	FirstFindTime   *float64 `json:"firstFindTime,omitempty"`   // instant de la première découverte
+	LastFindTime    *float64 `json:"lastFindTime,omitempty"`    // instant de la dernière découverte
+	TotalTime       *float64 `json:"totalTime,omitempty"`       // durée du run (fin ou limite de pas)
+	Energy          *float64 `json:"energy,omitempty"`          // énergie consommée par la flotte (Wh)
+	Recharges       *float64 `json:"recharges,omitempty"`       // nombre de recharges
+	FleetCost       *float64 `json:"fleetCost,omitempty"`       // coût de la flotte en euros
+	UnvisitedArea   *float64 `json:"unvisitedArea,omitempty"`   // part de la carte jamais survolée (0..1)
+	NoRescuePenalty *float64 `json:"noRescuePenalty,omitempty"` // score fixe si personne n'est sauvé (0 = pas de cas particulier)
+}
+
+// Poids effectifs d'une ScoringConfig
+type ScoringWeights struct {
+	Profile         string
+	RescueRate      float64
+	FirstFindTime   float64
+	LastFindTime    float64
+	TotalTime       float64
+	Energy          float64
+	Recharges       float64
+	FleetCost       float64
+	UnvisitedArea   float64
+	NoRescuePenalty float64
+}
+
+// Profils de mission prédéfinis
+var scoringProfiles = map[string]ScoringWeights{
+	// historique : plus de survivants et moins de temps = meilleur
+	"default": {RescueRate: 1000, TotalTime: -1, NoRescuePenalty: -1e9},
+	// premier contact le plus tôt possible
+	"first-contact": {RescueRate: 1000, FirstFindTime: -5, TotalTime: -0.2, NoRescuePenalty: -1e9},
+	// couverture complète de la zone
+	"coverage": {RescueRate: 1000, TotalTime: -0.5, UnvisitedArea: -500, NoRescuePenalty: -1e9},
+	// mission économe : flotte et énergie comptent. Un drone consomme de
+	// l'ordre de 1 Wh par seconde de vol : -0,05 par Wh coûte environ 0,05
+	// par seconde de vol et par drone
+	"economy": {RescueRate: 1000, TotalTime: -0.5, Energy: -0.05, Recharges: -5, FleetCost: -0.002, NoRescuePenalty: -1e9},
+}
+
+// Poids effectifs : profil de base, surchargé par les poids donnés (même nuls).
+// Sans profil, les poids donnés partent de zéro ; sans profil ni poids, on
+// retombe sur le profil "default".
+func (sc ScoringConfig) resolved() ScoringWeights {
+	name := sc.Profile
+	if name == "" && !sc.hasWeights() {
+		name = "default"
+	}
+	var base ScoringWeights
+	if name != "" {
+		// profil inconnu (refusé au chargement) : on rend compte du profil appliqué
+		if _, ok := scoringProfiles[name]; !ok {
+			name = "default"
+		}
+		base = scoringProfiles[name]
+	}
+	base.Profile = name
+
+	for _, w := range []struct {
+		dst *float64
+		src *float64
+	}{
+		{&base.RescueRate, sc.RescueRate},
+		{&base.FirstFindTime, sc.FirstFindTime},
+		{&base.LastFindTime, sc.LastFindTime},
+		{&base.TotalTime, sc.TotalTime},
+		{&base.Energy, sc.Energy},
+		{&base.Recharges, sc.Recharges},
+		{&base.FleetCost, sc.FleetCost},
+		{&base.UnvisitedArea, sc.UnvisitedArea},
+		{&base.NoRescuePenalty, sc.NoRescuePenalty},
+	} {
+		if w.src != nil {
+			*w.dst = *w.src
+		}
+	}
+	return base
+}
+
+func (sc ScoringConfig) hasWeights() bool {
+	return sc.RescueRate != nil || sc.FirstFindTime != nil || sc.LastFindTime != nil ||
+		sc.TotalTime != nil || sc.Energy != nil || sc.Recharges != nil ||
+		sc.FleetCost != nil || sc.UnvisitedArea != nil || sc.NoRescuePenalty != nil
+}
+
+func validateScoringProfile(name string) error {
+	if _, ok := scoringProfiles[name]; name != "" && !ok {
+		return fmt.Errorf("profil de score inconnu %q", name)
+	}
+	return nil
+}
+
+// Fonction de score configurable (voir ScoringConfig)
+func scoreStats(stats SimStats, sc ScoringConfig) float64 {
+	w := sc.resolved()
+	if w.NoRescuePenalty != 0 && (stats.TotalSurvivors == 0 || stats.SavedSurvivors == 0) {
+		return w.NoRescuePenalty
+	}
+	return w.RescueRate*rescueRate(stats) +
+		w.FirstFindTime*stats.FirstFindTime +
+		w.LastFindTime*stats.LastFindTime +
+		w.TotalTime*stats.TotalTime +
+		w.Energy*stats.EnergyConsumed +
+		w.Recharges*float64(stats.Recharges) +
+		w.FleetCost*stats.FleetCost +
+		w.UnvisitedArea*stats.UnvisitedFraction
+}
@@ -0,0 +1,40 @@
+package main
+
+import "testing"
+
+func TestScoringZeroWeightOverrides(t *testing.T) {
+	zero := 0.0
+	// un poids à 0 annule le terme du profil
+	w := ScoringConfig{Profile: "default", TotalTime: &zero}.resolved()
+	if w.TotalTime != 0 || w.RescueRate != 1000 {
+		t.Errorf("resolved = %+v, want totalTime 0 and the default rescueRate", w)
+	}
+	// un poids absent garde la valeur du profil
+	if w := (ScoringConfig{Profile: "economy"}).resolved(); w.FleetCost != scoringProfiles["economy"].FleetCost {
+		t.Errorf("economy fleetCost = %v", w.FleetCost)
+	}
+	// noRescuePenalty à 0 : un run sans sauvetage est noté comme les autres
+	st := SimStats{TotalSurvivors: 3, TotalTime: 100}
+	if got := scoreStats(st, ScoringConfig{Profile: "default", NoRescuePenalty: &zero}); got != -100 {
+		t.Errorf("score without penalty = %v, want -100", got)
+	}
+	if got := scoreStats(st, ScoringConfig{}); got != -1e9 {
+		t.Errorf("default score = %v, want the no-rescue penalty", got)
+	}
+	// comme tout poids donné sans profil, noRescuePenalty seul part de zéro
+	penalty := -5.0
+	if w := (ScoringConfig{NoRescuePenalty: &penalty}).resolved(); w.Profile != "" || w.TotalTime != 0 || w.NoRescuePenalty != -5 {
+		t.Errorf("noRescuePenalty alone resolved to %+v", w)
+	}
+}
+
+func TestUnknownScoringProfileRejected(t *testing.T) {
+	if w := (ScoringConfig{Profile: "economie"}).resolved(); w.Profile != "default" {
+		t.Errorf("unknown profile reported as %q, want the default actually applied", w.Profile)
+	}
+	cfg := defaultConfig()
+	cfg.Scoring.Profile = "economie"
+	if _, err := readConfig(writeTestConfig(t, cfg)); err == nil {
+		t.Error("config with an unknown scoring profile accepted")
+	}
+}
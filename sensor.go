@@ -0,0 +1,85 @@
+package main
+
+import (
+	"math"
+	"math/rand"
+)
+
+//
+// ------------------------ Capteurs ------------------------
+//
+// Chaque type de drone a son capteur : probabilité de détection par pas qui
+// baisse avec la distance, taux de fausses alertes (traces fantômes qui
+// mobilisent des renforts pour rien) et capacité à confirmer seul une
+// détection. Avec requireConfirmation, un survivant ne compte comme sauvé
+// qu'après une seconde détection par un autre drone ou une détection par un
+// capteur qui confirme (drone lourd).
+
+type SensorModel struct {
+	DetectionProb     float64 `json:"detectionProb,omitempty"`     // probabilité par pas à courte distance (0 = 1)
+	Falloff           float64 `json:"falloff,omitempty"`           // baisse relative de la probabilité en limite de portée (0 = disque)
+	FalsePositiveRate float64 `json:"falsePositiveRate,omitempty"` // fausses traces par seconde de vol
+	Confirms          bool    `json:"confirms,omitempty"`          // une détection de ce capteur suffit
+}
+
+// Probabilité de détection par pas à la distance dist pour une portée reach
+func (m SensorModel) probability(dist, reach float64) float64 {
+	p := m.DetectionProb
+	if p <= 0 || p > 1 {
+		p = 1
+	}
+	if m.Falloff > 0 && reach > 0 {
+		p *= 1 - math.Min(1, m.Falloff)*math.Min(1, dist/reach)
+	}
+	return p
+}
+
+// Tirage de détection d'une cible en (x, y) à distance dist : capteur du drone
+// puis terrain. Un capteur parfait en terrain dégagé ne consomme pas le RNG.
+func (e *Environment) detects(dr *Drone, x, y, dist, reach float64, rng *rand.Rand) bool {
+	p := dr.Sensor.probability(dist, reach) * e.Terrain.detection(x, y, e.Config.TimeStep)
+	if p >= 1 || rng.Float64() < p {
+		return true
+	}
+	dr.MissedDetections++
+	return false
+}
+
+// Fausse alerte éventuelle sur ce pas : une trace fantôme apparaît dans le
+// champ du drone et déclenche un appel à l'aide comme une vraie trace. Elle
+// est rangée dans env.Alerts, à la suite des numéros des vraies traces.
+func (d *DroneAgent) falsePositive(env *Environment, dr *Drone, detRadius float64) {
+	rate := dr.Sensor.FalsePositiveRate
+	if rate <= 0 || d.rng.Float64() >= rate*env.Config.TimeStep {
+		return
+	}
+	cfg := env.Config
+	x, y, ok := sampleOutsideZones(env.Zones, 20, func() (float64, float64) {
+		r := detRadius * math.Sqrt(d.rng.Float64())
+		a := d.rng.Float64() * 2 * math.Pi
+		return math.Max(0, math.Min(cfg.Width, dr.X+r*math.Cos(a))),
+			math.Max(0, math.Min(cfg.Height, dr.Y+r*math.Sin(a)))
+	})
+	if !ok {
+		return
+	}
+	tr := Trace{
+		ID:         len(env.Traces) + len(env.Alerts),
+		X:          x,
+		Y:          y,
+		Radius:     cfg.DetectionRadius * cfg.TailleIndice,
+		SurvivorID: -1,
+		Activated:  true,
+		Spurious:   true,
+		ReportedBy: dr.ID,
+	}
+	env.Alerts = append(env.Alerts, tr)
+	dr.FalsePositives++
+	env.logEvent(Event{Type: EventFalsePositive, Drone: dr.ID, Trace: ref(tr.ID)})
+	d.requestHelp(env, x, y, ref(tr.ID), nil)
+}
+
+// La détection de dr suffit-elle à compter le survivant comme sauvé ?
+func confirmsSurvivor(cfg SimConfig, dr *Drone, s *Survivor) bool {
+	return !cfg.RequireConfirmation || dr.Sensor.Confirms || (s.Detected && s.DetectedBy != dr.ID)
+}
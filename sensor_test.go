@@ -0,0 +1,40 @@
+package main
+
+import "testing"
+
+func TestFalseAlertsStayOutOfTraces(t *testing.T) {
+	cfg := testConfig(3)
+	cfg.NumDrones = 0
+	cfg.DroneTypes = []DroneType{{Name: "noisy", Count: 4, Speed: 50, Autonomy: 1000, Sensor: SensorModel{FalsePositiveRate: 2}}}
+	s := NewSimulation(cfg)
+	start := s.Snapshot()
+	for i := 0; i < 200; i++ {
+		s.step()
+	}
+	env := s.Snapshot()
+
+	if len(env.Alerts) == 0 {
+		t.Fatal("no false alert raised")
+	}
+	if len(env.Traces) != len(start.Traces) {
+		t.Errorf("traces grew from %d to %d", len(start.Traces), len(env.Traces))
+	}
+	stats := env.computeStats()
+	if stats.Traces != len(start.Traces) || stats.FalsePositives != len(env.Alerts) {
+		t.Errorf("stats count %d traces and %d false positives, want %d and %d",
+			stats.Traces, stats.FalsePositives, len(start.Traces), len(env.Alerts))
+	}
+	// numéros à la suite des vraies traces
+	for i, a := range env.Alerts {
+		if !a.Spurious || a.ID != len(env.Traces)+i {
+			t.Fatalf("alert %d = %+v", i, a)
+		}
+	}
+	// les alertes passent par le delta, sans keyframe
+	if !sameShape(&start, &env) {
+		t.Error("false alerts changed the stream shape")
+	}
+	if d := diffState(&start, &env); d == nil || len(d.Alerts) != len(env.Alerts) {
+		t.Error("delta does not carry the new alerts")
+	}
+}
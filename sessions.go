@@ -0,0 +1,240 @@
+package main
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"log"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+)
+
+//
+// ------------------------ Sessions ------------------------
+//
+// Chaque session a sa propre simulation, sa config et sa boucle Run. Une
+// session sans spectateur ni requête depuis idleTimeout est supprimée.
+
+const (
+	defaultIdleTimeout = 10 * time.Minute
+	sessionGCInterval  = 30 * time.Second
+)
+
+type Session struct {
+	ID      string
+	sim     *Simulation
+	base    SimConfig // config de départ de la session (reset sans paramètres)
+	created time.Time
+	ctx     context.Context // annulé à la suppression de la session
+	cancel  context.CancelFunc
+
+	mu       sync.Mutex
+	lastSeen time.Time
+}
+
+func (s *Session) touch() {
+	s.mu.Lock()
+	s.lastSeen = time.Now()
+	s.mu.Unlock()
+}
+
+// Session inactive : aucun flux ouvert et aucune requête depuis idle
+func (s *Session) idle(now time.Time, idle time.Duration) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.sim.viewers() == 0 && now.Sub(s.lastSeen) > idle
+}
+
+// Résumé d'une session pour GET /api/sims
+type SessionInfo struct {
+	ID       string    `json:"id"`
+	Created  time.Time `json:"created"`
+	LastSeen time.Time `json:"lastSeen"`
+	Viewers  int       `json:"viewers"`
+	Drones   int       `json:"drones"`
+	Seed     int64     `json:"seed"`
+	ControlState
+}
+
+func (s *Session) info() SessionInfo {
+	s.mu.Lock()
+	lastSeen := s.lastSeen
+	s.mu.Unlock()
+
+	s.sim.mu.RLock()
+	drones, seed := len(s.sim.env.Drones), s.sim.env.Config.Seed
+	s.sim.mu.RUnlock()
+	return SessionInfo{
+		ID:           s.ID,
+		Created:      s.created,
+		LastSeen:     lastSeen,
+		Viewers:      s.sim.viewers(),
+		Drones:       drones,
+		Seed:         seed,
+		ControlState: s.sim.controlState(),
+	}
+}
+
+type SessionManager struct {
+	mu          sync.Mutex
+	sessions    map[string]*Session
+	base        SimConfig // config du serveur (config.json + policy apprise)
+	idleTimeout time.Duration
+}
+
+func NewSessionManager(base SimConfig, idleTimeout time.Duration) *SessionManager {
+	return &SessionManager{
+		sessions:    map[string]*Session{},
+		base:        base,
+		idleTimeout: idleTimeout,
+	}
+}
+
+// Crée une session et lance sa simulation
+func (m *SessionManager) create(cfg SimConfig) *Session {
+	ctx, cancel := context.WithCancel(context.Background())
+	now := time.Now()
+	sess := &Session{
+		ID:       newSessionID(),
+		sim:      NewSimulation(cfg),
+		base:     cfg,
+		created:  now,
+		ctx:      ctx,
+		cancel:   cancel,
+		lastSeen: now,
+	}
+	go sess.sim.Run(ctx)
+
+	m.mu.Lock()
+	m.sessions[sess.ID] = sess
+	m.mu.Unlock()
+	log.Printf("Session %s created", sess.ID)
+	return sess
+}
+
+func (m *SessionManager) get(id string) (*Session, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	sess, ok := m.sessions[id]
+	return sess, ok
+}
+
+func (m *SessionManager) remove(id string) bool {
+	m.mu.Lock()
+	sess, ok := m.sessions[id]
+	delete(m.sessions, id)
+	m.mu.Unlock()
+	if ok {
+		sess.cancel()
+		sess.sim.stopRecording()
+		log.Printf("Session %s closed", id)
+	}
+	return ok
+}
+
+// Supprime périodiquement les sessions inactives
+func (m *SessionManager) collect(ctx context.Context) {
+	ticker := time.NewTicker(sessionGCInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-ticker.C:
+			m.collectIdle(now)
+		}
+	}
+}
+
+// Supprime les sessions inactives à l'instant now
+func (m *SessionManager) collectIdle(now time.Time) {
+	m.mu.Lock()
+	var stale []string
+	for id, sess := range m.sessions {
+		if sess.idle(now, m.idleTimeout) {
+			stale = append(stale, id)
+		}
+	}
+	m.mu.Unlock()
+	for _, id := range stale {
+		m.remove(id)
+	}
+}
+
+func newSessionID() string {
+	b := make([]byte, 6)
+	if _, err := rand.Read(b); err != nil {
+		panic(err) // crypto/rand ne doit pas échouer
+	}
+	return hex.EncodeToString(b)
+}
+
+// Handler d'une route /api/sims/{id}/...
+type sessionHandler func(w http.ResponseWriter, r *http.Request, sess *Session)
+
+func (m *SessionManager) route(h sessionHandler) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id := r.PathValue("id")
+		sess, ok := m.get(id)
+		if !ok {
+			writeAPIError(w, http.StatusNotFound, APIError{
+				Code:    "unknown_session",
+				Message: "session inconnue ou expirée",
+				Details: map[string]any{"id": id},
+			})
+			return
+		}
+		sess.touch()
+		h(w, r, sess)
+	}
+}
+
+// GET /api/sims : sessions ouvertes, les plus récentes d'abord
+func (m *SessionManager) handleList(w http.ResponseWriter, r *http.Request) {
+	m.mu.Lock()
+	list := make([]*Session, 0, len(m.sessions))
+	for _, sess := range m.sessions {
+		list = append(list, sess)
+	}
+	m.mu.Unlock()
+
+	sort.Slice(list, func(i, j int) bool { return list[i].created.After(list[j].created) })
+	infos := make([]SessionInfo, len(list))
+	for i, sess := range list {
+		infos[i] = sess.info()
+	}
+	writeJSON(w, infos)
+}
+
+// POST /api/sims : nouvelle session, mêmes paramètres que reset
+func (m *SessionManager) handleCreate(w http.ResponseWriter, r *http.Request) {
+	var reqCfg SimConfig
+	if !decodeBody(w, r, &reqCfg) {
+		return
+	}
+	cfg, apiErr := requestConfig(m.base, reqCfg)
+	if apiErr != nil {
+		writeAPIError(w, http.StatusBadRequest, *apiErr)
+		return
+	}
+	sess := m.create(cfg)
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	writeJSON(w, sess.info())
+}
+
+// DELETE /api/sims/{id}
+func (m *SessionManager) handleDelete(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+	if !m.remove(id) {
+		writeAPIError(w, http.StatusNotFound, APIError{
+			Code:    "unknown_session",
+			Message: "session inconnue ou expirée",
+			Details: map[string]any{"id": id},
+		})
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
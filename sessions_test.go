@@ -0,0 +1,114 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func newTestSessions(t *testing.T) (*SessionManager, *http.ServeMux) {
+	t.Helper()
+	m := NewSessionManager(testConfig(1), time.Minute)
+	t.Cleanup(func() {
+		for _, id := range sessionIDs(m) {
+			m.remove(id)
+		}
+	})
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /api/sims", m.handleCreate)
+	mux.HandleFunc("DELETE /api/sims/{id}", m.handleDelete)
+	mux.HandleFunc("GET /api/sims/{id}/state", m.route(handleGetState))
+	return m, mux
+}
+
+// Sessions ouvertes
+func sessionIDs(m *SessionManager) []string {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var ids []string
+	for id := range m.sessions {
+		ids = append(ids, id)
+	}
+	return ids
+}
+
+func serveTest(mux *http.ServeMux, method, path, body string) *httptest.ResponseRecorder {
+	w := httptest.NewRecorder()
+	mux.ServeHTTP(w, httptest.NewRequest(method, path, strings.NewReader(body)))
+	return w
+}
+
+func TestSessionLifecycle(t *testing.T) {
+	m, mux := newTestSessions(t)
+
+	w := serveTest(mux, "POST", "/api/sims", `{"seed": 5}`)
+	if w.Code != http.StatusCreated {
+		t.Fatalf("create: %d %s", w.Code, w.Body)
+	}
+	var info SessionInfo
+	if err := json.Unmarshal(w.Body.Bytes(), &info); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := m.get(info.ID); !ok || info.Seed != 5 {
+		t.Fatalf("created session %+v not registered", info)
+	}
+
+	if w := serveTest(mux, "GET", "/api/sims/"+info.ID+"/state", ""); w.Code != http.StatusOK {
+		t.Errorf("state: %d %s", w.Code, w.Body)
+	}
+	if w := serveTest(mux, "DELETE", "/api/sims/"+info.ID, ""); w.Code != http.StatusNoContent {
+		t.Errorf("delete: %d %s", w.Code, w.Body)
+	}
+	if _, ok := m.get(info.ID); ok {
+		t.Error("deleted session still registered")
+	}
+
+	// session inconnue ou supprimée : 404 unknown_session
+	for _, req := range [][2]string{
+		{"GET", "/api/sims/" + info.ID + "/state"},
+		{"DELETE", "/api/sims/" + info.ID},
+		{"GET", "/api/sims/nope/state"},
+	} {
+		w := serveTest(mux, req[0], req[1], "")
+		var body struct{ Error APIError }
+		json.Unmarshal(w.Body.Bytes(), &body)
+		if w.Code != http.StatusNotFound || body.Error.Code != "unknown_session" {
+			t.Errorf("%s %s: %d %s, want 404 unknown_session", req[0], req[1], w.Code, w.Body)
+		}
+	}
+}
+
+func TestCollectRemovesOnlyIdleSessionsWithoutViewers(t *testing.T) {
+	m, _ := newTestSessions(t)
+	idle := m.create(testConfig(1))
+	watched := m.create(testConfig(2))
+	recent := m.create(testConfig(3))
+
+	long := time.Now().Add(-2 * m.idleTimeout)
+	for _, sess := range []*Session{idle, watched} {
+		sess.mu.Lock()
+		sess.lastSeen = long
+		sess.mu.Unlock()
+	}
+	viewer := watched.sim.subscribe()
+	defer watched.sim.unsubscribe(viewer)
+
+	m.collectIdle(time.Now())
+	if _, ok := m.get(idle.ID); ok {
+		t.Error("idle session without viewers kept")
+	}
+	if _, ok := m.get(watched.ID); !ok {
+		t.Error("session with a viewer removed")
+	}
+	if _, ok := m.get(recent.ID); !ok {
+		t.Error("recently used session removed")
+	}
+	select {
+	case <-idle.ctx.Done():
+	default:
+		t.Error("removed session not cancelled")
+	}
+}
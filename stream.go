@@ -0,0 +1,337 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"slices"
+	"sync"
+	"time"
+)
+
+//
+// ------------------------ Diffusion en direct (SSE) ------------------------
+//
+// Chaque client reçoit une image complète (keyframe) à la connexion, après un
+// reset et toutes les keyframeEvery publications, puis un delta par tick :
+// drones modifiés, survivants nouvellement détectés ou sauvés, traces et fausses
+// alertes modifiées et incréments de la heatmap.
+
+const (
+	keyframeEvery   = 100              // publications entre deux keyframes
+	streamBuffer    = 64               // messages en attente par client avant resynchronisation
+	streamKeepAlive = 15 * time.Second // commentaire SSE pour garder la connexion ouverte
+)
+
+// Champs d'un drone qui bougent pendant la simulation
+type DroneDelta struct {
+	droneMotion
+	Route []Point `json:"route"` // null quand le drone n'a plus de chemin
+}
+
+type droneMotion struct {
+	ID                int       `json:"id"`
+	X                 float64   `json:"x"`
+	Y                 float64   `json:"y"`
+	Vx                float64   `json:"vx"`
+	Vy                float64   `json:"vy"`
+	Mode              DroneMode `json:"state"`
+	TargetX           float64   `json:"targetX"`
+	TargetY           float64   `json:"targetY"`
+	HasTarget         bool      `json:"hasTarget"`
+	Station           int       `json:"station"`
+	RemainingAutonomy float64   `json:"remainingAutonomy"`
+	RemainingEnergy   float64   `json:"remainingEnergy"`
+}
+
+func droneDelta(d Drone) DroneDelta {
+	return DroneDelta{droneMotion: droneMotion{
+		ID:                d.ID,
+		X:                 d.X,
+		Y:                 d.Y,
+		Vx:                d.Vx,
+		Vy:                d.Vy,
+		Mode:              d.Mode,
+		TargetX:           d.TargetX,
+		TargetY:           d.TargetY,
+		HasTarget:         d.HasTarget,
+		Station:           d.Station,
+		RemainingAutonomy: d.RemainingAutonomy,
+		RemainingEnergy:   d.RemainingEnergy,
+	}, Route: d.Route}
+}
+
+func (a DroneDelta) equal(b DroneDelta) bool {
+	return a.droneMotion == b.droneMotion && slices.Equal(a.Route, b.Route)
+}
+
+// Incrément d'une cellule de la heatmap
+type HeatDelta struct {
+	I int     `json:"i"`
+	J int     `json:"j"`
+	D float64 `json:"d"`
+}
+
+// Changements depuis la publication précédente (seq-1)
+type StateDelta struct {
+	Seq      int64           `json:"seq"`
+	Time     float64         `json:"time"`
+	Finished bool            `json:"finished"`
+	Stats    *SimStats       `json:"stats,omitempty"` // seulement quand la simulation se termine
+	Drones   []DroneDelta    `json:"drones,omitempty"`
+	Saved    []Survivor      `json:"saved,omitempty"` // survivants nouvellement détectés ou sauvés
+	Traces   []Trace         `json:"traces,omitempty"`
+	Alerts   []Trace         `json:"alerts,omitempty"`         // fausses alertes nouvelles ou levées
+	Stations []ChargingPoint `json:"chargingPoints,omitempty"` // stations dont l'occupation a changé
+	Wind     *Point          `json:"wind,omitempty"`           // vent au centre, quand il change
+	Heat     []HeatDelta     `json:"heat,omitempty"`
+}
+
+// Image complète : les deltas suivants partent de seq
+type Keyframe struct {
+	Seq   int64       `json:"seq"`
+	State Environment `json:"state"`
+}
+
+// Delta entre deux états de la même simulation (nil si rien n'a changé)
+func diffState(prev, cur *Environment) *StateDelta {
+	d := &StateDelta{Time: cur.Time, Finished: cur.Finished}
+	for i := range cur.Drones {
+		if dd := droneDelta(cur.Drones[i]); !dd.equal(droneDelta(prev.Drones[i])) {
+			d.Drones = append(d.Drones, dd)
+		}
+	}
+	for i, s := range cur.Survivors {
+		if (s.Saved && !prev.Survivors[i].Saved) || (s.Detected && !prev.Survivors[i].Detected) {
+			d.Saved = append(d.Saved, s)
+		}
+	}
+	for i, tr := range cur.Traces {
+		if tr != prev.Traces[i] {
+			d.Traces = append(d.Traces, tr)
+		}
+	}
+	// les fausses alertes ne font que s'ajouter : pas besoin de keyframe
+	for i, a := range cur.Alerts {
+		if i >= len(prev.Alerts) || a != prev.Alerts[i] {
+			d.Alerts = append(d.Alerts, a)
+		}
+	}
+	for i, cp := range cur.ChargingPoints {
+		if !sameStation(cp, prev.ChargingPoints[i]) {
+			d.Stations = append(d.Stations, cp)
+		}
+	}
+	if cur.Wind != prev.Wind {
+		wind := cur.Wind
+		d.Wind = &wind
+	}
+	for i := range cur.Heatmap {
+		for j, v := range cur.Heatmap[i] {
+			if inc := v - prev.Heatmap[i][j]; inc != 0 {
+				d.Heat = append(d.Heat, HeatDelta{I: i, J: j, D: inc})
+			}
+		}
+	}
+	if cur.Finished && !prev.Finished {
+		stats := cur.Stats
+		d.Stats = &stats
+	}
+
+	if d.Time == prev.Time && d.Finished == prev.Finished && d.Drones == nil &&
+		d.Saved == nil && d.Traces == nil && d.Alerts == nil && d.Stations == nil && d.Wind == nil && d.Heat == nil {
+		return nil
+	}
+	return d
+}
+
+// Même forme de monde : sinon (reset) il faut une keyframe
+func sameShape(a, b *Environment) bool {
+	if len(a.Drones) != len(b.Drones) || len(a.Survivors) != len(b.Survivors) ||
+		len(a.Traces) != len(b.Traces) || len(a.ChargingPoints) != len(b.ChargingPoints) ||
+		len(a.Heatmap) != len(b.Heatmap) {
+		return false
+	}
+	for i := range a.Heatmap {
+		if len(a.Heatmap[i]) != len(b.Heatmap[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+type streamClient struct {
+	ch     chan []byte
+	resync bool // a raté des messages : le prochain envoi sera une keyframe
+}
+
+// Diffuseur d'une simulation : un seul delta sérialisé pour tous les clients
+type streamHub struct {
+	mu       sync.Mutex
+	clients  map[*streamClient]struct{}
+	seq      int64
+	gen      int         // génération (reset) de l'état publié
+	last     Environment // dernier état publié
+	hasLast  bool
+	sinceKey int
+}
+
+func newStreamHub() *streamHub {
+	return &streamHub{clients: map[*streamClient]struct{}{}}
+}
+
+// Publie l'état courant aux clients connectés (appelé à chaque tick de Run)
+func (s *Simulation) publish() {
+	h := s.stream
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if len(h.clients) == 0 {
+		h.hasLast = false
+		return
+	}
+
+	s.mu.RLock()
+	if !h.hasLast || s.gen != h.gen || h.sinceKey >= keyframeEvery || !sameShape(&h.last, &s.env) {
+		h.last = s.env.clone()
+		h.gen = s.gen
+		s.mu.RUnlock()
+		h.hasLast = true
+		h.sinceKey = 0
+		h.seq++
+		h.broadcastKeyframe()
+		return
+	}
+	d := diffState(&h.last, &s.env)
+	if d == nil {
+		s.mu.RUnlock()
+		return
+	}
+	h.last = s.env.clone()
+	s.mu.RUnlock()
+
+	h.seq++
+	h.sinceKey++
+	d.Seq = h.seq
+	msg, err := sseFrame("delta", h.seq, d)
+	if err != nil {
+		log.Println("stream: delta non sérialisable:", err)
+		return
+	}
+	var keyframe []byte
+	for c := range h.clients {
+		if c.resync {
+			if keyframe == nil {
+				if keyframe, err = sseFrame("keyframe", h.seq, Keyframe{Seq: h.seq, State: h.last}); err != nil {
+					log.Println("stream: keyframe non sérialisable:", err)
+					return
+				}
+			}
+			c.send(keyframe)
+			continue
+		}
+		if !c.send(msg) {
+			c.resync = true
+		}
+	}
+}
+
+func (h *streamHub) broadcastKeyframe() {
+	msg, err := sseFrame("keyframe", h.seq, Keyframe{Seq: h.seq, State: h.last})
+	if err != nil {
+		log.Println("stream: keyframe non sérialisable:", err)
+		return
+	}
+	for c := range h.clients {
+		c.resync = !c.send(msg)
+	}
+}
+
+// Envoi non bloquant : un client lent ne ralentit ni la simulation ni les autres
+func (c *streamClient) send(msg []byte) bool {
+	select {
+	case c.ch <- msg:
+		c.resync = false
+		return true
+	default:
+		return false
+	}
+}
+
+// Nouveau client : il reçoit tout de suite une keyframe de l'état publié
+func (s *Simulation) subscribe() *streamClient {
+	h := s.stream
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if !h.hasLast {
+		s.mu.RLock()
+		h.last = s.env.clone()
+		h.gen = s.gen
+		s.mu.RUnlock()
+		h.hasLast = true
+		h.sinceKey = 0
+	}
+	c := &streamClient{ch: make(chan []byte, streamBuffer), resync: true}
+	if msg, err := sseFrame("keyframe", h.seq, Keyframe{Seq: h.seq, State: h.last}); err == nil {
+		c.send(msg)
+	}
+	h.clients[c] = struct{}{}
+	return c
+}
+
+// Nombre de clients connectés au flux
+func (s *Simulation) viewers() int {
+	s.stream.mu.Lock()
+	defer s.stream.mu.Unlock()
+	return len(s.stream.clients)
+}
+
+func (s *Simulation) unsubscribe(c *streamClient) {
+	s.stream.mu.Lock()
+	defer s.stream.mu.Unlock()
+	delete(s.stream.clients, c)
+}
+
+func sseFrame(event string, id int64, v any) ([]byte, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	return []byte(fmt.Sprintf("id: %d\nevent: %s\ndata: %s\n\n", id, event, data)), nil
+}
+
+func handleStream(w http.ResponseWriter, r *http.Request, sess *Session) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		writeAPIError(w, http.StatusInternalServerError, APIError{Code: "streaming_unsupported", Message: "streaming non supporté"})
+		return
+	}
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+
+	c := sess.sim.subscribe()
+	defer sess.sim.unsubscribe(c)
+
+	keepAlive := time.NewTicker(streamKeepAlive)
+	defer keepAlive.Stop()
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case <-sess.ctx.Done():
+			return // session supprimée
+		case msg := <-c.ch:
+			if _, err := w.Write(msg); err != nil {
+				return
+			}
+			flusher.Flush()
+		case <-keepAlive.C:
+			if _, err := w.Write([]byte(": ping\n\n")); err != nil {
+				return
+			}
+			flusher.Flush()
+		}
+	}
+}
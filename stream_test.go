@@ -0,0 +1,218 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"math"
+	"reflect"
+	"testing"
+)
+
+// Prochain message SSE en attente pour le client
+func nextFrame(t *testing.T, c *streamClient) (event string, data []byte) {
+	t.Helper()
+	select {
+	case msg := <-c.ch:
+		for _, line := range bytes.Split(msg, []byte("\n")) {
+			if v, ok := bytes.CutPrefix(line, []byte("event: ")); ok {
+				event = string(v)
+			}
+			if v, ok := bytes.CutPrefix(line, []byte("data: ")); ok {
+				data = v
+			}
+		}
+		return event, data
+	default:
+		t.Fatal("no message waiting for the client")
+		return "", nil
+	}
+}
+
+// État reconstruit par un client, comme applyDelta dans web/app.js
+type streamReplica struct {
+	seq   int64
+	world Environment
+}
+
+func (r *streamReplica) read(t *testing.T, c *streamClient) string {
+	t.Helper()
+	event, data := nextFrame(t, c)
+	switch event {
+	case "keyframe":
+		var k Keyframe
+		if err := json.Unmarshal(data, &k); err != nil {
+			t.Fatal(err)
+		}
+		r.seq, r.world = k.Seq, k.State
+	case "delta":
+		var d StateDelta
+		if err := json.Unmarshal(data, &d); err != nil {
+			t.Fatal(err)
+		}
+		if d.Seq != r.seq+1 {
+			t.Fatalf("delta %d applied on seq %d", d.Seq, r.seq)
+		}
+		r.seq = d.Seq
+		r.apply(t, d)
+	default:
+		t.Fatalf("unexpected event %q", event)
+	}
+	return event
+}
+
+func (r *streamReplica) apply(t *testing.T, d StateDelta) {
+	w := &r.world
+	w.Time, w.Finished = d.Time, d.Finished
+	if d.Stats != nil {
+		w.Stats = *d.Stats
+	}
+	for _, dd := range d.Drones {
+		// Object.assign : seuls les champs du delta changent
+		data, _ := json.Marshal(dd)
+		if err := json.Unmarshal(data, &w.Drones[dd.ID]); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for _, s := range d.Saved {
+		w.Survivors[s.ID] = s
+	}
+	for _, tr := range d.Traces {
+		w.Traces[tr.ID] = tr
+	}
+	for _, a := range d.Alerts {
+		if i := a.ID - len(w.Traces); i < len(w.Alerts) {
+			w.Alerts[i] = a
+		} else {
+			w.Alerts = append(w.Alerts, a)
+		}
+	}
+	for _, cp := range d.Stations {
+		w.ChargingPoints[cp.ID] = cp
+	}
+	if d.Wind != nil {
+		w.Wind = *d.Wind
+	}
+	for _, h := range d.Heat {
+		w.Heatmap[h.I][h.J] += h.D
+	}
+}
+
+// Compare l'état du client à l'état vivant, sur ce que le flux transporte
+func checkReplica(t *testing.T, label string, got, want Environment) {
+	t.Helper()
+	var live Environment
+	data, _ := json.Marshal(want)
+	if err := json.Unmarshal(data, &live); err != nil {
+		t.Fatal(err)
+	}
+
+	if got.Time != live.Time || got.Finished != live.Finished || got.Wind != live.Wind {
+		t.Fatalf("%s: time %v finished %v wind %v, want %v %v %v", label,
+			got.Time, got.Finished, got.Wind, live.Time, live.Finished, live.Wind)
+	}
+	for i := range live.Drones {
+		if !droneDelta(got.Drones[i]).equal(droneDelta(live.Drones[i])) {
+			t.Fatalf("%s: drone %d = %+v, want %+v", label, i, droneDelta(got.Drones[i]), droneDelta(live.Drones[i]))
+		}
+	}
+	for _, pair := range [][2]any{
+		{got.Survivors, live.Survivors},
+		{got.Traces, live.Traces},
+		{got.Alerts, live.Alerts},
+	} {
+		if reflect.ValueOf(pair[0]).Len()+reflect.ValueOf(pair[1]).Len() > 0 && !reflect.DeepEqual(pair[0], pair[1]) {
+			t.Fatalf("%s: %+v, want %+v", label, pair[0], pair[1])
+		}
+	}
+	for i := range live.ChargingPoints {
+		if !sameStation(got.ChargingPoints[i], live.ChargingPoints[i]) {
+			t.Fatalf("%s: station %d = %+v, want %+v", label, i, got.ChargingPoints[i], live.ChargingPoints[i])
+		}
+	}
+	for i := range live.Heatmap {
+		for j, v := range live.Heatmap[i] {
+			if math.Abs(got.Heatmap[i][j]-v) > 1e-9 {
+				t.Fatalf("%s: heat (%d, %d) = %v, want %v", label, i, j, got.Heatmap[i][j], v)
+			}
+		}
+	}
+}
+
+// Un pas, publié ; le monde reste en cours tout le test
+func publishStep(t *testing.T, s *Simulation) {
+	t.Helper()
+	s.step()
+	if s.Snapshot().Finished {
+		t.Fatal("simulation finished during the test")
+	}
+	s.publish()
+}
+
+func streamTestConfig(seed int64) SimConfig {
+	cfg := testConfig(seed)
+	cfg.NumSurvivors = 40
+	return cfg
+}
+
+func TestStreamDeltasRebuildLiveState(t *testing.T) {
+	s := NewSimulation(streamTestConfig(11))
+	c := s.subscribe()
+	var r streamReplica
+	if ev := r.read(t, c); ev != "keyframe" {
+		t.Fatalf("first message is a %s", ev)
+	}
+
+	// keyframeEvery deltas, puis une keyframe
+	for i := 1; i <= keyframeEvery+1; i++ {
+		publishStep(t, s)
+		ev := r.read(t, c)
+		if want := map[bool]string{true: "keyframe", false: "delta"}[i == keyframeEvery+1]; ev != want {
+			t.Fatalf("publication %d is a %s, want a %s", i, ev, want)
+		}
+		checkReplica(t, "publication", r.world, s.Snapshot())
+	}
+
+	// reset : nouvelle génération, keyframe même si le monde garde sa forme
+	s.Reset(streamTestConfig(12))
+	s.publish()
+	if ev := r.read(t, c); ev != "keyframe" {
+		t.Fatalf("after a reset: %s, want a keyframe", ev)
+	}
+	checkReplica(t, "reset", r.world, s.Snapshot())
+	publishStep(t, s)
+	if ev := r.read(t, c); ev != "delta" {
+		t.Fatalf("after the reset keyframe: %s, want a delta", ev)
+	}
+	checkReplica(t, "after reset", r.world, s.Snapshot())
+}
+
+func TestSlowStreamClientResyncs(t *testing.T) {
+	s := NewSimulation(streamTestConfig(13))
+	fast, slow := s.subscribe(), s.subscribe()
+	var rf, rs streamReplica
+	rf.read(t, fast)
+	rs.read(t, slow)
+
+	// le client lent ne lit rien : son tampon déborde
+	for i := 0; i < streamBuffer+5; i++ {
+		publishStep(t, s)
+		rf.read(t, fast)
+	}
+	checkReplica(t, "fast client", rf.world, s.Snapshot())
+
+	// il vide son retard : des deltas continus, puis une keyframe au lieu des messages perdus
+	for i := 0; i < streamBuffer; i++ {
+		if ev := rs.read(t, slow); ev != "delta" {
+			t.Fatalf("buffered message %d is a %s", i, ev)
+		}
+	}
+	publishStep(t, s)
+	rf.read(t, fast)
+	if ev := rs.read(t, slow); ev != "keyframe" {
+		t.Fatalf("slow client got a %s, want a keyframe", ev)
+	}
+	checkReplica(t, "slow client", rs.world, s.Snapshot())
+	if rs.seq != rf.seq {
+		t.Errorf("slow client at seq %d, fast client at %d", rs.seq, rf.seq)
+	}
+}
@@ -0,0 +1,250 @@
+package main
+
+import (
+	"fmt"
+	"image"
+	_ "image/jpeg"
+	_ "image/png"
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+//
+// ------------------------ Terrain ------------------------
+//
+// Le terrain découpe la carte en cellules ; chaque type de terrain donne la
+// probabilité, à chaque pas, qu'un drone à portée détecte réellement un
+// survivant ou une trace situé dans la cellule.
+
+type TerrainType string
+
+const (
+	TerrainOpen   TerrainType = "open"   // terrain dégagé
+	TerrainForest TerrainType = "forest" // forêt
+	TerrainRubble TerrainType = "rubble" // décombres urbains
+	TerrainWater  TerrainType = "water"  // eau
+)
+
+// Ordre des types dans la grille, lettre de la carte ASCII et couleur du raster
+var terrainTypes = []struct {
+	Type   TerrainType
+	Letter byte
+	RGB    [3]float64
+	Detect float64 // probabilité de détection par seconde d'exposition par défaut
+}{
+	{TerrainOpen, '.', [3]float64{200, 220, 160}, 1.0},
+	{TerrainForest, 'f', [3]float64{34, 100, 34}, 0.55},
+	{TerrainRubble, 'u', [3]float64{140, 120, 100}, 0.8},
+	{TerrainWater, 'w', [3]float64{40, 90, 200}, 0.97},
+}
+
+const defaultTerrainCell = 20.0
+
+type TerrainRegion struct {
+	Type    TerrainType `json:"type"`
+	Polygon []Point     `json:"polygon"`
+}
+
+// Bloc "terrain" de la config. Rows est une carte ASCII étirée sur la carte
+// ('.' dégagé, 'f' forêt, 'u' décombres, 'w' eau) ; Image est un raster
+// PNG/JPEG converti en Rows au chargement ; les régions sont appliquées par-dessus.
+type TerrainConfig struct {
+	CellSize  float64                 `json:"cellSize,omitempty"`  // taille d'une cellule (20 par défaut)
+	Default   TerrainType             `json:"default,omitempty"`   // terrain hors carte et régions (open)
+	Detection map[TerrainType]float64 `json:"detection,omitempty"` // surcharge des probabilités par seconde, par type
+	Rows      []string                `json:"rows,omitempty"`
+	Image     string                  `json:"image,omitempty"` // relatif au fichier de config
+	Regions   []TerrainRegion         `json:"regions,omitempty"`
+}
+
+func (tc TerrainConfig) empty() bool {
+	return len(tc.Rows) == 0 && len(tc.Regions) == 0 && len(tc.Detection) == 0 &&
+		(tc.Default == "" || tc.Default == TerrainOpen)
+}
+
+func validateTerrain(tc TerrainConfig) error {
+	known := func(t TerrainType) bool {
+		_, ok := terrainIndex(t)
+		return ok
+	}
+	if tc.CellSize < 0 {
+		return fmt.Errorf("terrain : cellSize négatif")
+	}
+	if tc.Default != "" && !known(tc.Default) {
+		return fmt.Errorf("terrain par défaut inconnu %q (open, forest, rubble, water)", tc.Default)
+	}
+	for t, p := range tc.Detection {
+		if !known(t) {
+			return fmt.Errorf("terrain inconnu %q dans detection (open, forest, rubble, water)", t)
+		}
+		if p < 0 || p > 1 {
+			return fmt.Errorf("probabilité de détection de %s hors de [0, 1] : %v", t, p)
+		}
+	}
+	for i, row := range tc.Rows {
+		for j := 0; j < len(row); j++ {
+			if _, ok := terrainLetter(row[j]); !ok {
+				return fmt.Errorf("terrain : lettre inconnue %q ligne %d (., f, u, w)", row[j], i)
+			}
+		}
+	}
+	for _, reg := range tc.Regions {
+		if !known(reg.Type) {
+			return fmt.Errorf("région de terrain de type inconnu %q (open, forest, rubble, water)", reg.Type)
+		}
+	}
+	return nil
+}
+
+// Grille de terrain renvoyée dans l'état
+type Terrain struct {
+	CellSize  float64       `json:"cellSize"`
+	W         int           `json:"w"`
+	H         int           `json:"h"`
+	Types     []TerrainType `json:"types"`     // types indexés par Cells
+	Detection []float64     `json:"detection"` // probabilité de détection par seconde de chaque type
+	Cells     []int         `json:"cells"`     // w*h, indice i + j*w
+}
+
+func terrainIndex(t TerrainType) (int, bool) {
+	for i, tt := range terrainTypes {
+		if tt.Type == t {
+			return i, true
+		}
+	}
+	return 0, false
+}
+
+func terrainLetter(c byte) (int, bool) {
+	for i, tt := range terrainTypes {
+		if tt.Letter == c {
+			return i, true
+		}
+	}
+	return 0, false
+}
+
+// Grille de terrain de la config ; nil si tout est dégagé
+func newTerrain(cfg SimConfig) *Terrain {
+	tc := cfg.Terrain
+	if tc.empty() {
+		return nil
+	}
+	cs := tc.CellSize
+	if cs <= 0 {
+		cs = defaultTerrainCell
+	}
+	w := int(math.Ceil(cfg.Width / cs))
+	h := int(math.Ceil(cfg.Height / cs))
+	t := &Terrain{CellSize: cs, W: w, H: h, Cells: make([]int, w*h)}
+	for _, tt := range terrainTypes {
+		p := tt.Detect
+		if v, ok := tc.Detection[tt.Type]; ok {
+			p = math.Max(0, math.Min(1, v))
+		}
+		t.Types = append(t.Types, tt.Type)
+		t.Detection = append(t.Detection, p)
+	}
+
+	// types inconnus refusés par validateTerrain : à défaut, dégagé
+	def, _ := terrainIndex(tc.Default)
+	for j := 0; j < h; j++ {
+		for i := 0; i < w; i++ {
+			k := def
+			x, y := (float64(i)+0.5)*cs, (float64(j)+0.5)*cs
+			if len(tc.Rows) > 0 {
+				row := tc.Rows[clampInt(int(y/cfg.Height*float64(len(tc.Rows))), 0, len(tc.Rows)-1)]
+				if len(row) > 0 {
+					if l, ok := terrainLetter(row[clampInt(int(x/cfg.Width*float64(len(row))), 0, len(row)-1)]); ok {
+						k = l
+					}
+				}
+			}
+			for _, reg := range tc.Regions {
+				if (Zone{Polygon: reg.Polygon}).contains(x, y) {
+					if l, ok := terrainIndex(reg.Type); ok {
+						k = l
+					}
+				}
+			}
+			t.Cells[i+j*w] = k
+		}
+	}
+	return t
+}
+
+// Probabilité de détecter une cible en (x, y) sur un pas de dt secondes. La
+// probabilité p du terrain vaut pour une seconde : c'est un taux
+// λ = -ln(1-p), soit 1-exp(-λ·dt) sur le pas, quel que soit le pas de temps.
+func (t *Terrain) detection(x, y, dt float64) float64 {
+	if t == nil {
+		return 1
+	}
+	i := clampInt(int(x/t.CellSize), 0, t.W-1)
+	j := clampInt(int(y/t.CellSize), 0, t.H-1)
+	p := t.Detection[t.Cells[i+j*t.W]]
+	if p >= 1 {
+		return 1
+	}
+	lambda := -math.Log1p(-p)
+	return 1 - math.Exp(-lambda*dt)
+}
+
+// Convertit le raster de la config en carte ASCII à la résolution de la grille
+func (cfg *SimConfig) loadTerrainImage(configDir string) error {
+	tc := &cfg.Terrain
+	if tc.Image == "" {
+		return nil
+	}
+	path := tc.Image
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(configDir, path)
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	img, _, err := image.Decode(f)
+	if err != nil {
+		return fmt.Errorf("%s: %w", path, err)
+	}
+
+	width, height := cfg.Width, cfg.Height
+	if width <= 0 || height <= 0 {
+		width, height = 1000, 700
+	}
+	cs := tc.CellSize
+	if cs <= 0 {
+		cs = defaultTerrainCell
+	}
+	w := int(math.Ceil(width / cs))
+	h := int(math.Ceil(height / cs))
+	b := img.Bounds()
+	rows := make([]string, h)
+	for j := 0; j < h; j++ {
+		var sb strings.Builder
+		for i := 0; i < w; i++ {
+			px := b.Min.X + clampInt(int((float64(i)+0.5)/float64(w)*float64(b.Dx())), 0, b.Dx()-1)
+			py := b.Min.Y + clampInt(int((float64(j)+0.5)/float64(h)*float64(b.Dy())), 0, b.Dy()-1)
+			r, g, bl, _ := img.At(px, py).RGBA()
+			sb.WriteByte(nearestTerrainLetter(float64(r>>8), float64(g>>8), float64(bl>>8)))
+		}
+		rows[j] = sb.String()
+	}
+	tc.Rows = rows
+	return nil
+}
+
+func nearestTerrainLetter(r, g, b float64) byte {
+	best, bestD := terrainTypes[0].Letter, math.Inf(1)
+	for _, tt := range terrainTypes {
+		d := math.Pow(r-tt.RGB[0], 2) + math.Pow(g-tt.RGB[1], 2) + math.Pow(b-tt.RGB[2], 2)
+		if d < bestD {
+			best, bestD = tt.Letter, d
+		}
+	}
+	return best
+}
@@ -0,0 +1,53 @@
+package main
+
+import (
+	"math"
+	"testing"
+)
+
+func TestTerrainDetectionIsARate(t *testing.T) {
+	cfg := defaultConfig()
+	cfg.Terrain = TerrainConfig{Default: TerrainForest}
+	tr := newTerrain(cfg)
+	p := tr.Detection[1] // forêt
+
+	if got := tr.detection(10, 10, 1); math.Abs(got-p) > 1e-12 {
+		t.Errorf("detection over 1 s = %v, want %v", got, p)
+	}
+	// dix pas de 0,1 s ratés ont la même probabilité qu'une seconde ratée
+	miss := math.Pow(1-tr.detection(10, 10, 0.1), 10)
+	if math.Abs(miss-(1-p)) > 1e-12 {
+		t.Errorf("miss over 10 x 0.1 s = %v, want %v", miss, 1-p)
+	}
+	cfg.Terrain = TerrainConfig{Default: TerrainForest, Detection: map[TerrainType]float64{TerrainForest: 1}}
+	if got := newTerrain(cfg).detection(10, 10, 0.1); got != 1 {
+		t.Errorf("certain detection per second gives %v per step", got)
+	}
+}
+
+func TestTerrainValidation(t *testing.T) {
+	// seule la surcharge de détection : la grille existe quand même
+	cfg := defaultConfig()
+	cfg.Terrain = TerrainConfig{Detection: map[TerrainType]float64{TerrainOpen: 0.6}}
+	if tr := newTerrain(cfg); tr == nil || tr.Detection[0] != 0.6 {
+		t.Errorf("detection override dropped: %+v", tr)
+	}
+
+	for _, tc := range []TerrainConfig{
+		{Default: "swamp"},
+		{Regions: []TerrainRegion{{Type: "lava", Polygon: []Point{{0, 0}, {10, 0}, {10, 10}}}}},
+		{Detection: map[TerrainType]float64{"sand": 0.5}},
+		{Detection: map[TerrainType]float64{TerrainForest: 1.5}},
+		{Rows: []string{"..x"}},
+	} {
+		if validateTerrain(tc) == nil {
+			t.Errorf("%+v accepted", tc)
+		}
+		if _, apiErr := requestConfig(defaultConfig(), SimConfig{Terrain: tc}); apiErr == nil || apiErr.Code != "invalid_terrain" {
+			t.Errorf("%+v: got %v, want invalid_terrain", tc, apiErr)
+		}
+	}
+	if err := validateTerrain(TerrainConfig{Default: TerrainWater, Rows: []string{".fuw"}}); err != nil {
+		t.Errorf("valid terrain rejected: %v", err)
+	}
+}
@@ -2,6 +2,13 @@ const canvas = document.getElementById("sim-canvas");
 const ctx = canvas.getContext("2d");
 
 const toggleBtn = document.getElementById("toggle-btn");
+const speedSelect = document.getElementById("speed-select");
+const stepBtn = document.getElementById("step-btn");
+const stepCount = document.getElementById("step-count");
+const untilSurvivorBtn = document.getElementById("until-survivor-btn");
+const untilTimeBtn = document.getElementById("until-time-btn");
+const untilTime = document.getElementById("until-time");
+const controlError = document.getElementById("control-error");
 const statusText = document.getElementById("status-text");
 const configForm = document.getElementById("config-form");
 const heatmapToggle = document.getElementById("toggle-heatmap");
@@ -13,41 +20,185 @@ heatmapToggle.addEventListener("change", (e) => {
 let currentWorld = null;
 let running = true;
 let showHeatmap = true;
+let catalog = null; // { budget, droneTypes } servi par /api/catalog
+let sessionId = null; // session rejointe (/api/sims/{id})
+
+// Chemin d'une route de la session courante
+function simPath(p) {
+  return `/api/sims/${sessionId}${p}`;
+}
+
+// --- budget ---
+const fleetInputs = document.getElementById("fleet-inputs");
+const budgetDisplay = document.getElementById("budget-display");
+const totalCostDisplay = document.getElementById("total-cost");
+const errorMsg = document.getElementById("budget-error");
+const submitBtn = configForm.querySelector("button[type='submit']");
+
+async function apiCatalog() {
+  const res = await fetch("/api/catalog");
+  if (!res.ok) {
+    console.error("Erreur catalog", res.status);
+    return;
+  }
+  catalog = await res.json();
+}
+
+// Un champ par type du catalogue (prix et caractéristiques viennent du serveur)
+function buildFleetInputs() {
+  fleetInputs.innerHTML = "";
+  catalog.droneTypes.forEach((dt, i) => {
+    const last = i === catalog.droneTypes.length - 1;
+    const div = document.createElement("div");
+    div.className = "drone-select";
+    div.style.cssText = last
+      ? ""
+      : "margin-bottom: 8px; border-bottom: 1px solid #444; padding-bottom: 8px;";
+    div.innerHTML = `
+      <strong>${dt.label || dt.name} (${dt.price.toLocaleString("fr-FR")} €)</strong><br>
+      <small>Vitesse : ${dt.speed} | Détection : ${dt.detectionRadius}m | Autonomie : ${dt.autonomy}s | Poids : ${dt.weight}kg</small>
+      <input type="number" class="drone-input" data-type="${dt.name}" data-price="${dt.price}"
+             value="${dt.count || 0}" min="0" style="width: 100%; margin-top: 5px;" />`;
+    div.querySelector("input").addEventListener("input", updateBudget);
+    fleetInputs.appendChild(div);
+  });
+  updateBudget();
+}
+
+function showError(message) {
+  errorMsg.textContent = message;
+  errorMsg.style.display = message ? "block" : "none";
+}
+
+function updateBudget() {
+  let total = 0;
+  fleetInputs.querySelectorAll(".drone-input").forEach((input) => {
+    const price = Number(input.dataset.price);
+    const count = parseInt(input.value) || 0;
+    total += price * count;
+  });
+
+  totalCostDisplay.textContent = total;
+  const remaining = catalog.budget - total;
+  budgetDisplay.textContent = remaining;
+
+  if (remaining < 0) {
+    showError("Budget dépassé !");
+    submitBtn.disabled = true;
+    budgetDisplay.style.color = "#ef4444";
+  } else {
+    showError("");
+    submitBtn.disabled = false;
+    budgetDisplay.style.color = "#22c55e";
+  }
+}
 
 
 async function apiReset(config) {
-  const res = await fetch("/api/reset", {
+  const res = await fetch(simPath("/reset"), {
     method: "POST",
     headers: { "Content-Type": "application/json" },
     body: JSON.stringify(config),
   });
   if (!res.ok) {
-    console.error("Erreur reset", res.status);
-    return;
+    // erreur structurée du serveur : { error: { code, message, details } }
+    const body = await res.json().catch(() => null);
+    const message = body && body.error ? body.error.message : `Erreur ${res.status}`;
+    console.error("Erreur reset", res.status, body);
+    showError(message);
+    return false;
   }
+  showError("");
   currentWorld = await res.json();
+  return true;
 }
 
-async function apiState() {
-  const res = await fetch("/api/state");
-  if (!res.ok) {
-    console.error("Erreur state", res.status);
-    return;
-  }
-  currentWorld = await res.json();
+// --- flux temps réel (/api/sims/{id}/stream) : keyframes + deltas par tick ---
+let stream = null;
+let lastSeq = null; // null : on attend une keyframe
+
+function openStream() {
+  if (stream) stream.close();
+  lastSeq = null;
+  stream = new EventSource(simPath("/stream"));
+
+  stream.addEventListener("keyframe", (e) => {
+    const kf = JSON.parse(e.data);
+    currentWorld = kf.state;
+    lastSeq = kf.seq;
+  });
+
+  stream.addEventListener("delta", (e) => {
+    const d = JSON.parse(e.data);
+    if (lastSeq === null) return;
+    if (d.seq !== lastSeq + 1) {
+      // message perdu : on se reconnecte pour repartir d'une keyframe
+      openStream();
+      return;
+    }
+    applyDelta(d);
+    lastSeq = d.seq;
+  });
+
+  stream.onerror = () => {
+    // EventSource se reconnecte seul ; la keyframe suivante resynchronise
+    lastSeq = null;
+  };
 }
 
-async function apiToggle() {
-  const res = await fetch("/api/toggle", { method: "POST" });
+function applyDelta(d, world = currentWorld) {
+  world.time = d.time;
+  world.finished = d.finished;
+  if (d.stats) world.stats = d.stats;
+  (d.drones || []).forEach((dd) => Object.assign(world.drones[dd.id], dd));
+  (d.saved || []).forEach((s) => (world.survivors[s.id] = s));
+  (d.traces || []).forEach((tr) => (world.traces[tr.id] = tr));
+  // fausses alertes : numérotées à la suite des traces
+  world.alerts = world.alerts || [];
+  (d.alerts || []).forEach((a) => (world.alerts[a.id - world.traces.length] = a));
+  (d.chargingPoints || []).forEach((cp) => (world.chargingPoints[cp.id] = cp));
+  if (d.wind) world.wind = d.wind;
+  (d.heat || []).forEach((c) => (world.heatmap[c.i][c.j] += c.d));
+}
+
+// Requête de contrôle : renvoie l'état des contrôles, ou null (erreur affichée)
+async function apiControl(path, body) {
+  const res = await fetch(simPath(path), {
+    method: "POST",
+    headers: { "Content-Type": "application/json" },
+    body: JSON.stringify(body || {}),
+  });
+  const data = await res.json().catch(() => null);
   if (!res.ok) {
-    console.error("Erreur toggle", res.status);
-    return;
+    const message = data && data.error ? data.error.message : `Erreur ${res.status}`;
+    console.error("Erreur", path, res.status, data);
+    controlError.textContent = message;
+    return null;
   }
-  const data = await res.json();
-  running = data.running;
+  controlError.textContent = "";
+  setControls(data);
+  return data;
+}
+
+function setControls(state) {
+  recording = state.recording || "";
+  recordBtn.textContent = recording ? "⏹ Arrêter l'enregistrement" : "⏺ Enregistrer";
+  running = state.running;
   toggleBtn.textContent = running ? "⏸ Pause" : "▶ Reprendre";
+  stepBtn.disabled = running || state.finished;
+  speedSelect.value = String(state.speed);
+}
+
+async function apiToggle() {
+  await apiControl("/toggle");
 }
 
+const TERRAIN_COLORS = {
+  forest: "rgba(34,100,34,0.35)",
+  rubble: "rgba(140,120,100,0.3)",
+  water: "rgba(40,90,200,0.3)",
+};
+
 function drawWorld() {
   if (!currentWorld) return;
 
@@ -66,8 +217,23 @@ function drawWorld() {
   ctx.fillStyle = "#020617"; // très sombre
   ctx.fillRect(0, 0, w, h);
 
+  // --- Terrain (le dégagé reste sur le fond) ---
+  const terrain = currentWorld.terrain;
+  if (terrain) {
+    const cw = terrain.cellSize * scaleX;
+    const chh = terrain.cellSize * scaleY;
+    for (let j = 0; j < terrain.h; j++) {
+      for (let i = 0; i < terrain.w; i++) {
+        const color = TERRAIN_COLORS[terrain.types[terrain.cells[i + j * terrain.w]]];
+        if (!color) continue;
+        ctx.fillStyle = color;
+        ctx.fillRect(i * cw, j * chh, cw + 0.5, chh + 0.5);
+      }
+    }
+  }
+
   // --- Traces de vie : n'afficher QUE la partie en intersection avec un ou plusieurs champs de vision ---
-  traces.forEach((tr) => {
+  traces.concat(currentWorld.alerts || []).forEach((tr) => {
     if (tr.consumed) return; // la trace est "morte" côté back
 
     // On récupère les drones dont le champ de vision intersecte la trace
@@ -152,46 +318,55 @@ function drawWorld() {
   }
 
 
-// --- budget ---
-const MAX_BUDGET = 100000;
-const droneInputs = document.querySelectorAll(".drone-input");
-const budgetDisplay = document.getElementById("budget-display");
-const totalCostDisplay = document.getElementById("total-cost");
-const errorMsg = document.getElementById("budget-error");
-const submitBtn = configForm.querySelector("button[type='submit']");
+  // --- Obstacles (gris) et zones interdites (rouge, hachurées) ---
+  (currentWorld.zones || []).forEach((z) => {
+    if (!z.polygon || z.polygon.length < 3) return;
+    ctx.beginPath();
+    z.polygon.forEach((p, i) => {
+      const px = p.x * scaleX;
+      const py = p.y * scaleY;
+      if (i === 0) ctx.moveTo(px, py);
+      else ctx.lineTo(px, py);
+    });
+    ctx.closePath();
 
-function updateBudget() {
-  let total = 0;
-  droneInputs.forEach(input => {
-    const price = parseInt(input.dataset.price);
-    const count = parseInt(input.value) || 0;
-    total += price * count;
+    if (z.kind === "nofly") {
+      ctx.fillStyle = "rgba(239,68,68,0.12)";
+      ctx.fill();
+      ctx.save();
+      ctx.clip();
+      ctx.strokeStyle = "rgba(239,68,68,0.35)";
+      ctx.lineWidth = 1;
+      ctx.beginPath();
+      for (let k = -h; k < w; k += 12) {
+        ctx.moveTo(k, 0);
+        ctx.lineTo(k + h, h);
+      }
+      ctx.stroke();
+      ctx.restore();
+      ctx.setLineDash([6, 4]);
+      ctx.strokeStyle = "rgba(239,68,68,0.9)";
+    } else {
+      ctx.fillStyle = "rgba(100,116,139,0.85)";
+      ctx.fill();
+      ctx.strokeStyle = "rgba(148,163,184,0.9)";
+    }
+    ctx.lineWidth = 1.5;
+    ctx.stroke();
+    ctx.setLineDash([]);
   });
 
-  totalCostDisplay.textContent = total;
-  const remaining = MAX_BUDGET - total;
-  budgetDisplay.textContent = remaining;
-
-  if (remaining < 0) {
-    errorMsg.style.display = "block";
-    submitBtn.disabled = true;
-    budgetDisplay.style.color = "#ef4444";
-  } else {
-    errorMsg.style.display = "none";
-    submitBtn.disabled = false;
-    budgetDisplay.style.color = "#22c55e";
-  }
-}
-
-droneInputs.forEach(input => {
-  input.addEventListener("input", updateBudget);
-});
-
-updateBudget();
-
-
   // --- Survivants : cachés tant qu'ils ne sont pas trouvés ---
   survivors.forEach((s) => {
+    if (!s.saved && s.detected) {
+      // détecté, en attente de confirmation : simple cercle
+      ctx.beginPath();
+      ctx.arc(s.x * scaleX, s.y * scaleY, (s.radius || 6) * scaleX, 0, Math.PI * 2);
+      ctx.strokeStyle = "rgba(249,115,22,0.95)";
+      ctx.lineWidth = 2;
+      ctx.stroke();
+      return;
+    }
     if (!s.saved) return; // on ignore les non trouvés
 
     ctx.beginPath();
@@ -208,7 +383,38 @@ updateBudget();
     ctx.arc(cp.x * scaleX, cp.y * scaleY, r, 0, Math.PI * 2);
     ctx.fillStyle = "rgba(255,215,0,0.95)"; // gold
     ctx.fill();
+
+    // occupation : emplacements utilisés / total, puis la file d'attente
+    const used = (cp.charging || []).length;
+    const queued = (cp.queue || []).length;
+    if (used > 0 || queued > 0) {
+      ctx.font = "11px system-ui";
+      ctx.fillStyle = "#e5e7eb";
+      const label = `${used}/${cp.pads}` + (queued > 0 ? ` +${queued}` : "");
+      ctx.fillText(label, cp.x * scaleX + r + 3, cp.y * scaleY + 4);
+    }
+  });
+
+  // --- Chemins planifiés (renfort en jaune, retour en rouge) ---
+  ctx.save();
+  ctx.setLineDash([4, 4]);
+  ctx.lineWidth = 1.5;
+  drones.forEach((d) => {
+    if (!d.route || d.route.length === 0) return;
+    ctx.beginPath();
+    ctx.moveTo(d.x * scaleX, d.y * scaleY);
+    d.route.forEach((p) => ctx.lineTo(p.x * scaleX, p.y * scaleY));
+    ctx.strokeStyle =
+      d.state === "returning" ? "rgba(255,0,0,0.6)" : "rgba(251,191,36,0.6)";
+    ctx.stroke();
+    d.route.slice(0, -1).forEach((p) => {
+      ctx.beginPath();
+      ctx.arc(p.x * scaleX, p.y * scaleY, 2.5, 0, Math.PI * 2);
+      ctx.fillStyle = ctx.strokeStyle;
+      ctx.fill();
+    });
   });
+  ctx.restore();
 
   // --- Drones + cercle de vision ---
   drones.forEach((d) => {
@@ -234,6 +440,10 @@ updateBudget();
       color = "rgba(34,197,94,0.95)"; // vert
     } else if (d.state === "returning") {
       color = "rgba(255,0,0,0.95)"; // rouge 
+    } else if (d.state === "waiting") {
+      color = "rgba(148,163,184,0.95)"; // gris
+    } else if (d.state === "charging") {
+      color = "rgba(255,215,0,0.95)"; // or, comme la station
     }
 
     const angle = Math.atan2(d.vy, d.vx);
@@ -253,6 +463,34 @@ updateBudget();
     ctx.restore();
   });
 
+  // --- Vent (au centre de la carte), en haut à droite ---
+  const wind = currentWorld.wind;
+  const windSpeed = wind ? Math.hypot(wind.x, wind.y) : 0;
+  if (windSpeed > 0.05) {
+    const cx = w - 40;
+    const cy = 34;
+    const len = 22;
+    ctx.save();
+    ctx.translate(cx, cy);
+    ctx.rotate(Math.atan2(wind.y, wind.x));
+    ctx.beginPath();
+    ctx.moveTo(-len / 2, 0);
+    ctx.lineTo(len / 2, 0);
+    ctx.moveTo(len / 2, 0);
+    ctx.lineTo(len / 2 - 6, -4);
+    ctx.moveTo(len / 2, 0);
+    ctx.lineTo(len / 2 - 6, 4);
+    ctx.strokeStyle = "rgba(226,232,240,0.9)";
+    ctx.lineWidth = 2;
+    ctx.stroke();
+    ctx.restore();
+    ctx.font = "11px system-ui";
+    ctx.fillStyle = "#e5e7eb";
+    ctx.textAlign = "center";
+    ctx.fillText(`Vent ${windSpeed.toFixed(1)} m/s`, cx, cy + 22);
+    ctx.textAlign = "start";
+  }
+
   const remaining = survivors.filter((s) => !s.saved).length;
   statusText.textContent = finished
     ? `Simulation terminée – Temps : ${stats.totalTime.toFixed(
@@ -271,7 +509,7 @@ updateBudget();
     ctx.globalAlpha = 0.85;
     ctx.fillStyle = "#020617";
     const boxW = w * 0.6;
-    const boxH = h * 0.35;
+    const boxH = h * 0.5;
     const boxX = (w - boxW) / 2;
     const boxY = (h - boxH) / 2;
     ctx.fillRect(boxX, boxY, boxW, boxH);
@@ -291,6 +529,10 @@ updateBudget();
       `Survivants sauvés : ${stats.savedSurvivors} / ${stats.totalSurvivors}`,
       `Nombre de drones : ${stats.drones}`,
       `Traces de vie utilisées : ${stats.tracesConsumed} / ${stats.traces}`,
+      `Fausses alertes : ${stats.falsePositives || 0} – Détections manquées : ${stats.missedDetections || 0}`,
+      `Attente aux stations : ${(stats.queueTime || 0).toFixed(1)} s – Charge : ${(stats.chargingTime || 0).toFixed(1)} s`,
+      `Messages : ${stats.messagesSent || 0} émis – ${stats.messagesReceived || 0} reçus – ${stats.messagesLost || 0} perdus`,
+      `Graine : ${stats.seed}`,
       "",
       'Clique sur "Appliquer & reset" pour relancer une nouvelle simulation.',
     ];
@@ -307,70 +549,277 @@ updateBudget();
   }
 }
 
-async function loop() {
-  try {
-    await apiState();
-  } catch (e) {
-    console.error(e);
-  }
+let lastFrame = performance.now();
 
+function loop(now = performance.now()) {
+  if (replay && replay.playing) advanceReplay(now - lastFrame);
+  lastFrame = now;
   drawWorld();
-  setTimeout(loop, 50);
+  requestAnimationFrame(loop);
 }
 
 toggleBtn.addEventListener("click", () => {
   apiToggle().catch(console.error);
 });
 
+speedSelect.addEventListener("change", () => {
+  const v = speedSelect.value;
+  apiControl("/speed", { speed: v === "max" ? "max" : Number(v) }).catch(console.error);
+});
+
+stepBtn.addEventListener("click", () => {
+  apiControl("/step", { n: Number(stepCount.value) || 1 }).catch(console.error);
+});
+
+untilSurvivorBtn.addEventListener("click", () => {
+  apiControl("/run-until", { condition: "survivor" }).catch(console.error);
+});
+
+untilTimeBtn.addEventListener("click", () => {
+  apiControl("/run-until", { condition: "time", time: Number(untilTime.value) }).catch(console.error);
+});
+
+// run-until met la simulation en pause côté serveur : on resynchronise les boutons
+setInterval(() => {
+  if (!sessionId) return;
+  fetch(simPath("/speed"))
+    .then((res) => {
+      if (res.status === 404) {
+        controlError.textContent = "Session expirée : créez-en une nouvelle.";
+        return null;
+      }
+      return res.json();
+    })
+    .then((state) => state && setControls(state))
+    .catch(console.error);
+}, 500);
+
+// --- sessions ---
+const sessionSelect = document.getElementById("session-select");
+const sessionJoinBtn = document.getElementById("session-join-btn");
+const sessionNewBtn = document.getElementById("session-new-btn");
+
+async function apiSessions() {
+  const res = await fetch("/api/sims");
+  if (!res.ok) {
+    console.error("Erreur sessions", res.status);
+    return [];
+  }
+  return res.json();
+}
+
+async function apiCreateSession(config) {
+  const res = await fetch("/api/sims", {
+    method: "POST",
+    headers: { "Content-Type": "application/json" },
+    body: JSON.stringify(config || {}),
+  });
+  const data = await res.json().catch(() => null);
+  if (!res.ok) {
+    showError(data && data.error ? data.error.message : `Erreur ${res.status}`);
+    return null;
+  }
+  return data.id;
+}
+
+async function refreshSessions() {
+  const sessions = await apiSessions();
+  sessionSelect.innerHTML = "";
+  sessions.forEach((s) => {
+    const opt = document.createElement("option");
+    opt.value = s.id;
+    const you = s.id === sessionId ? " (vous)" : "";
+    opt.textContent = `${s.id}${you} – t=${s.time.toFixed(0)}s, ${s.drones} drones, ${s.viewers} spectateur(s)`;
+    sessionSelect.appendChild(opt);
+  });
+  if (sessionId) sessionSelect.value = sessionId;
+  return sessions;
+}
+
+function joinSession(id) {
+  closeReplay();
+  sessionId = id;
+  location.hash = id;
+  currentWorld = null;
+  controlError.textContent = "";
+  openStream();
+  refreshSessions().catch(console.error);
+}
+
+sessionJoinBtn.addEventListener("click", () => {
+  if (sessionSelect.value) joinSession(sessionSelect.value);
+});
+
+sessionNewBtn.addEventListener("click", async () => {
+  const id = await apiCreateSession({});
+  if (id) joinSession(id);
+});
+
+setInterval(() => refreshSessions().catch(console.error), 5000);
+
+// --- replays : enregistrement de la session et relecture sans re-simuler ---
+const recordBtn = document.getElementById("record-btn");
+const recordEvery = document.getElementById("record-every");
+const replaySelect = document.getElementById("replay-select");
+const replayLoadBtn = document.getElementById("replay-load-btn");
+const replayLiveBtn = document.getElementById("replay-live-btn");
+const replayPanel = document.getElementById("replay-panel");
+const replayTimeline = document.getElementById("replay-timeline");
+const replayPlayBtn = document.getElementById("replay-play-btn");
+const replaySpeed = document.getElementById("replay-speed");
+const replayPos = document.getElementById("replay-pos");
+
+let recording = "";
+let replay = null; // { header, frames, index, playing, acc }
+
+async function refreshReplays() {
+  const res = await fetch("/api/replays");
+  if (!res.ok) return;
+  const list = await res.json();
+  const selected = replaySelect.value;
+  replaySelect.innerHTML = "";
+  list.forEach((r) => {
+    const opt = document.createElement("option");
+    opt.value = r.name;
+    opt.textContent = `${r.name} (${Math.round(r.size / 1024)} ko)`;
+    replaySelect.appendChild(opt);
+  });
+  if (selected) replaySelect.value = selected;
+}
+
+recordBtn.addEventListener("click", async () => {
+  if (recording) {
+    await apiControl("/record/stop");
+    recording = "";
+  } else {
+    await apiControl("/record", { every: Number(recordEvery.value) || 1 });
+  }
+  refreshReplays().catch(console.error);
+});
+
+async function loadReplay(name) {
+  const res = await fetch(`/api/replays/${encodeURIComponent(name)}`);
+  if (!res.ok) {
+    controlError.textContent = `Replay introuvable (${res.status})`;
+    return;
+  }
+  const lines = (await res.text()).split("\n").filter((l) => l);
+  const header = JSON.parse(lines[0]);
+  const frames = lines.slice(1).map((l) => JSON.parse(l));
+  if (frames.length === 0) return;
+
+  // le direct est coupé pendant la relecture
+  if (stream) stream.close();
+  stream = null;
+  replay = { header, frames, index: 0, playing: false, acc: 0 };
+  replayPanel.style.display = "";
+  replayLiveBtn.disabled = false;
+  replayTimeline.max = frames.length - 1;
+  replayPlayBtn.textContent = "▶";
+  seekReplay(0);
+}
+
+// Repart de la keyframe précédente et applique les deltas jusqu'à l'image i
+function seekReplay(i) {
+  const frames = replay.frames;
+  let k = i;
+  while (k > 0 && !frames[k].keyframe) k--;
+  const world = structuredClone(frames[k].keyframe.state);
+  for (let j = k + 1; j <= i; j++) applyDelta(frames[j].delta, world);
+  currentWorld = world;
+  replay.index = i;
+  showReplayPosition();
+}
+
+function stepReplay() {
+  const next = replay.frames[replay.index + 1];
+  if (next.keyframe) currentWorld = structuredClone(next.keyframe.state);
+  else applyDelta(next.delta, currentWorld);
+  replay.index++;
+}
+
+// Lecture : une image enregistrée = every pas de 50 ms à vitesse 1x
+function advanceReplay(dtMs) {
+  replay.acc += (dtMs / (50 * replay.header.every)) * Number(replaySpeed.value);
+  while (replay.acc >= 1 && replay.index < replay.frames.length - 1) {
+    stepReplay();
+    replay.acc -= 1;
+  }
+  if (replay.index >= replay.frames.length - 1) {
+    replay.playing = false;
+    replayPlayBtn.textContent = "▶";
+  }
+  showReplayPosition();
+}
+
+function showReplayPosition() {
+  replayTimeline.value = replay.index;
+  replayPos.textContent = `t=${currentWorld.time.toFixed(1)} s (${replay.index + 1}/${replay.frames.length})`;
+}
+
+function closeReplay() {
+  replay = null;
+  replayPanel.style.display = "none";
+  replayLiveBtn.disabled = true;
+}
+
+replayLoadBtn.addEventListener("click", () => {
+  if (replaySelect.value) loadReplay(replaySelect.value).catch(console.error);
+});
+
+replayLiveBtn.addEventListener("click", () => {
+  if (sessionId) joinSession(sessionId);
+});
+
+replayTimeline.addEventListener("input", () => {
+  if (replay) seekReplay(Number(replayTimeline.value));
+});
+
+replayPlayBtn.addEventListener("click", () => {
+  if (!replay) return;
+  if (replay.index >= replay.frames.length - 1) seekReplay(0);
+  replay.playing = !replay.playing;
+  replay.acc = 0;
+  replayPlayBtn.textContent = replay.playing ? "⏸" : "▶";
+});
+
+refreshReplays().catch(console.error);
+
 configForm.addEventListener("submit", async (e) => {
   e.preventDefault();
   const data = new FormData(configForm);
 
-  // Construction des types de drones basés sur les inputs
-  const droneTypes = [
-    {
-      name: "Scout",
-      count: Number(data.get("count_scout")),
-      speed: 90,
-      weight: 1.0,
-      autonomy: 15.0,
-      detectionRadius: 30
-    },
-    {
-      name: "Standard",
-      count: Number(data.get("count_standard")),
-      speed: 60,
-      weight: 2.0,
-      autonomy: 25.0,
-      detectionRadius: 50
-    },
-    {
-      name: "Heavy",
-      count: Number(data.get("count_heavy")),
-      speed: 30,
-      weight: 5.0,
-      autonomy: 40.0,
-      detectionRadius: 80
-    }
-  ];
-
-  const filteredTypes = droneTypes.filter(t => (t.count || 0) > 0);
+  // Nom et nombre seulement : le serveur complète depuis son catalogue
+  const droneTypes = [];
+  fleetInputs.querySelectorAll(".drone-input").forEach((input) => {
+    const count = parseInt(input.value) || 0;
+    if (count > 0) droneTypes.push({ name: input.dataset.type, count });
+  });
 
   const config = {
-    droneTypes: filteredTypes,
+    droneTypes,
     numSurvivors: Number(data.get("numSurvivors")),
+    knowledge: data.get("knowledge"),
   };
+  const seed = Number(data.get("seed"));
+  if (seed) config.seed = seed;
 
 
-  await apiReset(config);
+  if (!(await apiReset(config))) return;
+  lastSeq = null; // les deltas de l'ancien monde sont ignorés jusqu'à la keyframe
   toggleBtn.disabled = false;
   toggleBtn.textContent = "⏸ Pause";
   drawWorld();
 });
 
 (async function init() {
-  await apiReset({});
-  await apiState();
-  drawWorld();
+  await apiCatalog();
+  if (catalog) buildFleetInputs();
+  // session du lien (#id) si elle existe encore, sinon une nouvelle
+  const sessions = await apiSessions();
+  const wanted = location.hash.slice(1);
+  let id = sessions.some((s) => s.id === wanted) ? wanted : null;
+  if (!id) id = await apiCreateSession({});
+  if (id) joinSession(id);
   loop();
 })();
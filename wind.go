@@ -0,0 +1,271 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"math/rand"
+	"os"
+	"path/filepath"
+)
+
+//
+// ------------------------ Vent ------------------------
+//
+// Le vent s'ajoute à la vitesse air des drones. Un drone qui suit une route
+// corrige sa dérive (vol en crabe) et avance moins vite vent de face ; si le
+// vent est plus fort que lui, il est emporté. L'énergie dépend de la vitesse
+// air, donc du vent, et le retour à la charge prévoit le vent de face.
+
+type WindKind string
+
+const (
+	WindUniform WindKind = "uniform" // vent constant, éventuellement tournant (veer)
+	WindGusty   WindKind = "gusty"   // vent constant plus des rafales
+	WindSpatial WindKind = "field"   // champ de vecteurs lu d'un fichier
+)
+
+const (
+	defaultGustPeriod = 20.0 // s
+	windSamples       = 5    // points d'échantillonnage du vent sur une route
+)
+
+// Bloc "wind" de la config
+type WindConfig struct {
+	Kind       WindKind   `json:"kind,omitempty"`       // pas de vent si vide
+	Speed      float64    `json:"speed,omitempty"`      // m/s
+	Direction  float64    `json:"direction,omitempty"`  // degrés, sens vers lequel souffle le vent (0 = +x, 90 = +y)
+	Veer       float64    `json:"veer,omitempty"`       // rotation de la direction, degrés/s
+	Gust       float64    `json:"gust,omitempty"`       // amplitude des rafales, m/s (gusty : speed/2 par défaut)
+	GustPeriod float64    `json:"gustPeriod,omitempty"` // période des rafales, s
+	File       string     `json:"file,omitempty"`       // champ de vent JSON, relatif au fichier de config
+	Field      *WindField `json:"field,omitempty"`
+}
+
+// Champ de vent sur une grille ; les images sont interpolées dans le temps
+// et la dernière reste valable ensuite
+type WindField struct {
+	CellSize float64     `json:"cellSize"`
+	Frames   []WindFrame `json:"frames"`
+}
+
+type WindFrame struct {
+	Time float64     `json:"time"`
+	U    [][]float64 `json:"u"` // [ligne][colonne], composante x en m/s
+	V    [][]float64 `json:"v"` // composante y
+}
+
+// Vent de la simulation, immuable et partagé par les copies de l'état
+type Wind struct {
+	cfg    WindConfig
+	phases [2]float64 // phases des rafales, tirées au reset
+}
+
+func validateWind(cfg WindConfig) error {
+	switch cfg.Kind {
+	case "", WindUniform, WindGusty:
+	case WindSpatial:
+		if cfg.Field == nil || len(cfg.Field.Frames) == 0 || cfg.Field.CellSize <= 0 {
+			return fmt.Errorf("vent field sans champ (field avec cellSize et frames, ou file dans la config)")
+		}
+	default:
+		return fmt.Errorf("vent inconnu %q (uniform, gusty, field)", cfg.Kind)
+	}
+	if cfg.Speed < 0 || cfg.Gust < 0 || cfg.GustPeriod < 0 {
+		return fmt.Errorf("vent : speed, gust et gustPeriod doivent être positifs")
+	}
+	return nil
+}
+
+// Vent de la config ; nil sans vent
+func newWind(cfg WindConfig, rng *rand.Rand) *Wind {
+	switch cfg.Kind {
+	case "":
+		return nil
+	case WindSpatial:
+		if cfg.Field == nil || len(cfg.Field.Frames) == 0 || cfg.Field.CellSize <= 0 {
+			return nil
+		}
+	}
+	if cfg.Kind == WindGusty && cfg.Gust <= 0 {
+		cfg.Gust = cfg.Speed / 2
+	}
+	if cfg.GustPeriod <= 0 {
+		cfg.GustPeriod = defaultGustPeriod
+	}
+	w := &Wind{cfg: cfg}
+	if cfg.Gust > 0 {
+		w.phases = [2]float64{rng.Float64() * 2 * math.Pi, rng.Float64() * 2 * math.Pi}
+	}
+	return w
+}
+
+// Vent (m/s) en (x, y) à l'instant t
+func (w *Wind) at(x, y, t float64) (float64, float64) {
+	if w == nil {
+		return 0, 0
+	}
+	dir := (w.cfg.Direction + w.cfg.Veer*t) * math.Pi / 180
+	var wx, wy float64
+	if w.cfg.Kind == WindSpatial {
+		wx, wy = w.cfg.Field.at(x, y, t)
+	} else {
+		wx, wy = math.Cos(dir)*w.cfg.Speed, math.Sin(dir)*w.cfg.Speed
+	}
+	if w.cfg.Gust > 0 {
+		// deux sinusoïdes de périodes non commensurables : rafales irrégulières
+		p := w.cfg.GustPeriod
+		g := w.cfg.Gust * (0.6*math.Sin(2*math.Pi*t/p+w.phases[0]) + 0.4*math.Sin(2*math.Pi*t/(0.37*p)+w.phases[1]))
+		if s := math.Hypot(wx, wy); s > 0 {
+			wx, wy = wx+g*wx/s, wy+g*wy/s
+		} else {
+			wx, wy = wx+g*math.Cos(dir), wy+g*math.Sin(dir)
+		}
+	}
+	return wx, wy
+}
+
+// Vent de l'image de champ la plus proche dans l'espace, interpolé dans le temps
+func (f *WindField) at(x, y, t float64) (float64, float64) {
+	frames := f.Frames
+	k := 0
+	for k+1 < len(frames) && frames[k+1].Time <= t {
+		k++
+	}
+	u0, v0 := frames[k].at(x, y, f.CellSize)
+	if k+1 >= len(frames) || t <= frames[k].Time {
+		return u0, v0
+	}
+	u1, v1 := frames[k+1].at(x, y, f.CellSize)
+	a := (t - frames[k].Time) / (frames[k+1].Time - frames[k].Time)
+	return u0 + a*(u1-u0), v0 + a*(v1-v0)
+}
+
+func (fr *WindFrame) at(x, y, cellSize float64) (float64, float64) {
+	return gridValue(fr.U, x, y, cellSize), gridValue(fr.V, x, y, cellSize)
+}
+
+func gridValue(g [][]float64, x, y, cellSize float64) float64 {
+	if len(g) == 0 {
+		return 0
+	}
+	row := g[clampInt(int(y/cellSize), 0, len(g)-1)]
+	if len(row) == 0 {
+		return 0
+	}
+	return row[clampInt(int(x/cellSize), 0, len(row)-1)]
+}
+
+// Vitesse sol d'un drone qui vise la direction de (vx, vy) à sa vitesse air
+// |v| : il corrige le vent traversier pour tenir sa route ; si le vent est
+// trop fort pour avancer sur cette route, il dérive (vitesse air + vent).
+func groundVelocity(vx, vy, wx, wy float64) (float64, float64) {
+	va := math.Hypot(vx, vy)
+	if va == 0 {
+		return wx, wy
+	}
+	dx, dy := vx/va, vy/va
+	along := wx*dx + wy*dy
+	cross := wy*dx - wx*dy
+	if math.Abs(cross) < va {
+		if gs := along + math.Sqrt(va*va-cross*cross); gs > 0 {
+			return dx * gs, dy * gs
+		}
+	}
+	return vx + wx, vy + wy
+}
+
+// Vitesse sol moyenne prévue de (ax, ay) à (bx, by) à la vitesse air va, avec
+// le vent actuel et des rafales supposées de face ; 0 si la route n'est pas tenable
+func (w *Wind) groundSpeed(ax, ay, bx, by, va, t float64) float64 {
+	dist := distance(ax, ay, bx, by)
+	if dist == 0 {
+		return va
+	}
+	dx, dy := (bx-ax)/dist, (by-ay)/dist
+	var duration float64
+	for i := 0; i < windSamples; i++ {
+		f := (float64(i) + 0.5) / windSamples
+		wx, wy := w.at(ax+f*(bx-ax), ay+f*(by-ay), t)
+		along := wx*dx + wy*dy - w.cfg.Gust
+		cross := wy*dx - wx*dy
+		if math.Abs(cross) >= va {
+			return 0
+		}
+		gs := along + math.Sqrt(va*va-cross*cross)
+		if gs <= 0 {
+			return 0
+		}
+		duration += dist / windSamples / gs
+	}
+	return dist / duration
+}
+
+// Énergie prévue (Wh) pour rejoindre (x, y) par le chemin planifié autour
+// des zones, avec le vent actuel sur chaque segment ; infinie si le vent de
+// face l'empêche
+func (env *Environment) energyToReach(dr *Drone, x, y float64) float64 {
+	route := []Point{{X: x, Y: y}}
+	if p := env.planner; p != nil && !p.visible(dr.X, dr.Y, x, y) {
+		if r, ok := p.routeTo(dr.X, dr.Y, x, y); ok {
+			route = r
+		}
+	}
+	var energy float64
+	ax, ay := dr.X, dr.Y
+	for _, pt := range route {
+		energy += env.legEnergy(dr, ax, ay, pt.X, pt.Y)
+		ax, ay = pt.X, pt.Y
+	}
+	return energy
+}
+
+// Énergie prévue (Wh) pour un segment en ligne droite avec le vent actuel
+func (env *Environment) legEnergy(dr *Drone, ax, ay, bx, by float64) float64 {
+	dist := distance(ax, ay, bx, by)
+	if env.wind == nil {
+		return dr.energyToReach(dist)
+	}
+	gs := env.wind.groundSpeed(ax, ay, bx, by, dr.Speed, env.Time)
+	if gs <= 0 {
+		return math.Inf(1)
+	}
+	return dr.Airframe.power(dr.Speed) * dist / gs / 3600
+}
+
+// Énergie prévue pour rejoindre le point de charge le moins coûteux
+func (env *Environment) returnEnergy(dr *Drone) float64 {
+	best := math.Inf(1)
+	for _, cp := range env.ChargingPoints {
+		best = math.Min(best, env.energyToReach(dr, cp.X, cp.Y))
+	}
+	return best
+}
+
+// Vent au centre de la carte, exposé dans l'état pour l'affichage
+func (env *Environment) updateWind() {
+	wx, wy := env.wind.at(env.Config.Width/2, env.Config.Height/2, env.Time)
+	env.Wind = Point{X: wx, Y: wy}
+}
+
+// Lit le champ de vent du fichier de la config
+func (cfg *SimConfig) loadWindField(configDir string) error {
+	wc := &cfg.Wind
+	if wc.File == "" {
+		return nil
+	}
+	path := wc.File
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(configDir, path)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	var f WindField
+	if err := json.Unmarshal(data, &f); err != nil {
+		return err
+	}
+	wc.Field = &f
+	return nil
+}
@@ -0,0 +1,28 @@
+package main
+
+import "testing"
+
+func TestRequestWindValidated(t *testing.T) {
+	base := defaultConfig()
+	field := &WindField{CellSize: 100, Frames: []WindFrame{{U: [][]float64{{3}}, V: [][]float64{{0}}}}}
+	for _, tc := range []struct {
+		wind WindConfig
+		ok   bool
+	}{
+		{WindConfig{Kind: WindGusty, Speed: 5}, true},
+		{WindConfig{Kind: WindSpatial, Field: field}, true},
+		{WindConfig{Kind: "gusti", Speed: 5}, false},
+		// un fichier n'est jamais lu depuis l'API
+		{WindConfig{Kind: WindSpatial, File: "wind.json"}, false},
+		{WindConfig{Kind: WindUniform, Speed: -1}, false},
+	} {
+		req := SimConfig{Wind: tc.wind}
+		_, apiErr := requestConfig(base, req)
+		if tc.ok && apiErr != nil {
+			t.Errorf("%+v rejected: %v", tc.wind, apiErr.Message)
+		}
+		if !tc.ok && (apiErr == nil || apiErr.Code != "invalid_wind") {
+			t.Errorf("%+v: got %v, want invalid_wind", tc.wind, apiErr)
+		}
+	}
+}